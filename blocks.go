@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/pubsub"
+)
+
+// BlockEntry is one row of the block or mute list
+type BlockEntry struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Handle    string    `json:"handle,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// getBlockTarget gets the logged in user and the {UserID} they want to block or mute
+func (config *apiConfig) getBlockTarget(response http.ResponseWriter, request *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	targetID, err := uuid.Parse(request.PathValue("UserID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing the UUID of the user", err, http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+	if targetID == userID {
+		respondWithError(response, request, "You can't do that to yourself", nil, http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+	if _, err = config.dbQueries.GetUserByID(request.Context(), targetID); err != nil {
+		respondWithError(response, request, "Couldn't find the user...", err, http.StatusNotFound)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, targetID, true
+}
+
+// blockHandler hides the two users from each other and breaks any follows between them
+func (config *apiConfig) blockHandler(response http.ResponseWriter, request *http.Request) {
+	userID, blockedID, ok := config.getBlockTarget(response, request)
+	if !ok {
+		return
+	}
+
+	tx, err := config.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(response, request, "There was an error blocking the user", err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	queries := config.dbQueries.WithTx(tx)
+
+	if _, err = queries.BlockUser(request.Context(), database.BlockUserParams{
+		BlockerID: userID,
+		BlockedID: blockedID,
+	}); err != nil {
+		respondWithError(response, request, "There was an error blocking the user", err, http.StatusInternalServerError)
+		return
+	}
+
+	for _, follow := range []database.UnfollowUserParams{
+		{FollowerID: userID, FolloweeID: blockedID},
+		{FollowerID: blockedID, FolloweeID: userID},
+	} {
+		if _, err = queries.UnfollowUser(request.Context(), follow); err != nil {
+			respondWithError(response, request, "There was an error removing the follows", err, http.StatusInternalServerError)
+			return
+		}
+		if err = queries.RemoveFolloweeFromTimeline(request.Context(), database.RemoveFolloweeFromTimelineParams(follow)); err != nil {
+			respondWithError(response, request, "There was an error cleaning up the timelines", err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		respondWithError(response, request, "There was an error blocking the user", err, http.StatusInternalServerError)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func (config *apiConfig) unblockHandler(response http.ResponseWriter, request *http.Request) {
+	userID, blockedID, ok := config.getBlockTarget(response, request)
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := config.dbQueries.UnblockUser(request.Context(), database.UnblockUserParams{
+		BlockerID: userID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error unblocking the user", err, http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected == 0 {
+		respondWithError(response, request, "You haven't blocked that user", nil, http.StatusNotFound)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+// muteHandler hides a user's chirps from you without them knowing, unlike a block it doesn't affect them at all
+func (config *apiConfig) muteHandler(response http.ResponseWriter, request *http.Request) {
+	userID, mutedID, ok := config.getBlockTarget(response, request)
+	if !ok {
+		return
+	}
+
+	if _, err := config.dbQueries.MuteUser(request.Context(), database.MuteUserParams{
+		MuterID: userID,
+		MutedID: mutedID,
+	}); err != nil {
+		respondWithError(response, request, "There was an error muting the user", err, http.StatusInternalServerError)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func (config *apiConfig) unmuteHandler(response http.ResponseWriter, request *http.Request) {
+	userID, mutedID, ok := config.getBlockTarget(response, request)
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := config.dbQueries.UnmuteUser(request.Context(), database.UnmuteUserParams{
+		MuterID: userID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error unmuting the user", err, http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected == 0 {
+		respondWithError(response, request, "You haven't muted that user", nil, http.StatusNotFound)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func (config *apiConfig) getBlockedEntries(request *http.Request, userID uuid.UUID) ([]BlockEntry, error) {
+	rows, err := config.dbQueries.ListBlockedUsers(request.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []BlockEntry{}
+	for _, row := range rows {
+		entries = append(entries, BlockEntry{UserID: row.BlockedID, Handle: row.Handle.String, CreatedAt: row.CreatedAt})
+	}
+	return entries, nil
+}
+
+func (config *apiConfig) getMutedEntries(request *http.Request, userID uuid.UUID) ([]BlockEntry, error) {
+	rows, err := config.dbQueries.ListMutedUsers(request.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []BlockEntry{}
+	for _, row := range rows {
+		entries = append(entries, BlockEntry{UserID: row.MutedID, Handle: row.Handle.String, CreatedAt: row.CreatedAt})
+	}
+	return entries, nil
+}
+
+// blockListHandler makes the handlers for GET /api/users/me/blocks and /mutes. With export set the list is sent as a
+// CSV download instead of JSON
+func (config *apiConfig) blockListHandler(name string, export bool, list func(*http.Request, uuid.UUID) ([]BlockEntry, error)) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		_, userID, err := getJWTFromHeader(request.Header, config.secret)
+		if err != nil {
+			respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+			return
+		}
+
+		entries, err := list(request, userID)
+		if err != nil {
+			respondWithError(response, request, "There was an error fetching your "+name, err, http.StatusInternalServerError)
+			return
+		}
+
+		if !export {
+			respondWithJSON(response, request, entries, http.StatusOK)
+			return
+		}
+
+		response.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		response.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".csv"))
+		response.WriteHeader(http.StatusOK)
+
+		writer := csv.NewWriter(response)
+		writer.Write([]string{"user_id", "handle", "created_at"})
+		for _, entry := range entries {
+			writer.Write([]string{entry.UserID.String(), entry.Handle, entry.CreatedAt.UTC().Format(time.RFC3339)})
+		}
+		writer.Flush()
+	}
+}
+
+// hiddenUsers is whose chirps a stream shouldn't send to a user: everyone on either side of a block with them and
+// everyone they've muted. The hub checks it from other goroutines so it is locked
+type hiddenUsers struct {
+	mu      sync.Mutex
+	blocked map[uuid.UUID]struct{}
+	muted   map[uuid.UUID]struct{}
+}
+
+// loadHiddenUsers fills in (or refreshes) hidden for the user
+func (config *apiConfig) loadHiddenUsers(ctx context.Context, userID uuid.UUID, hidden *hiddenUsers) error {
+	rows, err := config.dbQueries.ListHiddenUsers(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	blocked, muted := map[uuid.UUID]struct{}{}, map[uuid.UUID]struct{}{}
+	for _, row := range rows {
+		if row.Muted {
+			muted[row.UserID] = struct{}{}
+		} else {
+			blocked[row.UserID] = struct{}{}
+		}
+	}
+
+	hidden.mu.Lock()
+	defer hidden.mu.Unlock()
+	hidden.blocked, hidden.muted = blocked, muted
+	return nil
+}
+
+// hides is true when the author's chirps should be left out. Like ?author_id= on /api/chirps, a muted author still
+// comes through when the user asked for them by name
+func (hidden *hiddenUsers) hides(authorID uuid.UUID, askedFor bool) bool {
+	hidden.mu.Lock()
+	defer hidden.mu.Unlock()
+	if _, ok := hidden.blocked[authorID]; ok {
+		return true
+	}
+	_, ok := hidden.muted[authorID]
+	return ok && !askedFor
+}
+
+// hidesEvent is like hides, but also leaves out rechirps and quotes of chirps from hidden users. Asking for the
+// rechirper by name doesn't bring back a muted original author
+func (hidden *hiddenUsers) hidesEvent(event pubsub.Event, askedFor bool) bool {
+	if hidden.hides(event.AuthorID, askedFor) {
+		return true
+	}
+	for _, authorID := range event.EmbeddedAuthorIDs {
+		if hidden.hides(authorID, false) {
+			return true
+		}
+	}
+	return false
+}
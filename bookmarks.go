@@ -0,0 +1,469 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/pagination"
+)
+
+const (
+	maxCollections          = 100
+	maxCollectionNameLength = 50
+)
+
+// Collection is a named, private list of chirps
+type Collection struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	ChirpCount int64     `json:"chirp_count"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// savedChirp is a row from the bookmarks or a collection, they're paged through by when they were saved
+type savedChirp struct {
+	chirp   database.Chirp
+	savedAt time.Time
+}
+
+// getSaveTarget gets the logged in user and the {ChirpID} they want to bookmark
+func (config *apiConfig) getSaveTarget(response http.ResponseWriter, request *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	chirpID, ok := config.findChirpToSave(response, request, userID, request.PathValue("ChirpID"))
+	return userID, chirpID, ok
+}
+
+// findChirpToSave checks the user can see the chirp they want to save. Saving a rechirp saves the original
+func (config *apiConfig) findChirpToSave(response http.ResponseWriter, request *http.Request, userID uuid.UUID, rawChirpID string) (uuid.UUID, bool) {
+	chirpID, err := uuid.Parse(rawChirpID)
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing that UUID", err, http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+
+	chirp, err := config.dbQueries.GetChirpForViewer(request.Context(), database.GetChirpForViewerParams{
+		ID:       chirpID,
+		ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil || chirp.TombstonedAt.Valid {
+		respondWithError(response, request, "Chirp not found", err, http.StatusNotFound)
+		return uuid.Nil, false
+	}
+	if chirp.RechirpOfID.Valid {
+		chirpID = chirp.RechirpOfID.UUID
+	}
+	return chirpID, true
+}
+
+// parseSavedPage reads ?limit= and ?before= for the bookmark and collection lists
+func parseSavedPage(response http.ResponseWriter, request *http.Request) (int, sql.NullTime, uuid.NullUUID, bool) {
+	query := request.URL.Query()
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(response, request, err.Error(), err, http.StatusBadRequest)
+		return 0, sql.NullTime{}, uuid.NullUUID{}, false
+	}
+
+	if before := query.Get("before"); before != "" {
+		cursor, err := pagination.DecodeCursor(before)
+		if err != nil {
+			respondWithError(response, request, "The 'before' cursor is invalid", err, http.StatusBadRequest)
+			return 0, sql.NullTime{}, uuid.NullUUID{}, false
+		}
+		return limit, sql.NullTime{Time: cursor.CreatedAt, Valid: true}, uuid.NullUUID{UUID: cursor.ID, Valid: true}, true
+	}
+	return limit, sql.NullTime{}, uuid.NullUUID{}, true
+}
+
+// respondWithSavedChirps sends a page of saved chirps. rows has up to limit+1 rows, the extra one means there's a next page
+func (config *apiConfig) respondWithSavedChirps(response http.ResponseWriter, request *http.Request, userID uuid.UUID, limit int, rows []savedChirp) {
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		next := pagination.EncodeCursor(pagination.Cursor{CreatedAt: last.savedAt, ID: last.chirp.ID})
+		response.Header().Set("Link", pagination.NextLink(request.URL, "before", next))
+	}
+
+	chirps := []Chirp{}
+	for _, row := range rows {
+		chirps = append(chirps, chirpFromDatabase(row.chirp))
+	}
+	if err := config.hydrateChirps(request.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirpPointers(chirps)); err != nil {
+		respondWithError(response, request, "There was an error loading the chirps", err, http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(response, request, chirps, http.StatusOK)
+}
+
+// addBookmarks sets bookmarked on the chirps the viewer has bookmarked. Nobody else ever finds out
+func (config *apiConfig) addBookmarks(ctx context.Context, viewerID uuid.NullUUID, chirps []*Chirp) error {
+	if !viewerID.Valid {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		chirpIDs = append(chirpIDs, chirp.ID)
+	}
+
+	bookmarked, err := config.dbQueries.GetBookmarkedChirpIDs(ctx, database.GetBookmarkedChirpIDsParams{
+		UserID:   viewerID.UUID,
+		ChirpIds: chirpIDs,
+	})
+	if err != nil {
+		return err
+	}
+	isBookmarked := map[uuid.UUID]bool{}
+	for _, chirpID := range bookmarked {
+		isBookmarked[chirpID] = true
+	}
+	for _, chirp := range chirps {
+		chirp.Bookmarked = isBookmarked[chirp.ID]
+	}
+	return nil
+}
+
+func (config *apiConfig) bookmarkHandler(response http.ResponseWriter, request *http.Request) {
+	userID, chirpID, ok := config.getSaveTarget(response, request)
+	if !ok {
+		return
+	}
+
+	if _, err := config.dbQueries.AddBookmark(request.Context(), database.AddBookmarkParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	}); err != nil {
+		respondWithError(response, request, "There was an error bookmarking the chirp", err, http.StatusInternalServerError)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func (config *apiConfig) removeBookmarkHandler(response http.ResponseWriter, request *http.Request) {
+	userID, chirpID, ok := config.getSaveTarget(response, request)
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := config.dbQueries.RemoveBookmark(request.Context(), database.RemoveBookmarkParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error removing the bookmark", err, http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected == 0 {
+		respondWithError(response, request, "You haven't bookmarked that chirp", nil, http.StatusNotFound)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+// getBookmarksHandler lists the user's own bookmarks, most recently saved first
+func (config *apiConfig) getBookmarksHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	limit, beforeCreatedAt, beforeID, ok := parseSavedPage(response, request)
+	if !ok {
+		return
+	}
+
+	rows, err := config.dbQueries.GetBookmarkedChirps(request.Context(), database.GetBookmarkedChirpsParams{
+		UserID:          userID,
+		BeforeCreatedAt: beforeCreatedAt,
+		BeforeID:        beforeID,
+		RowLimit:        int32(limit + 1),
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching your bookmarks", err, http.StatusInternalServerError)
+		return
+	}
+
+	saved := []savedChirp{}
+	for _, row := range rows {
+		saved = append(saved, savedChirp{chirp: row.Chirp, savedAt: row.SavedAt})
+	}
+	config.respondWithSavedChirps(response, request, userID, limit, saved)
+}
+
+// validateCollectionName writes the error response itself when the name isn't allowed
+func validateCollectionName(response http.ResponseWriter, request *http.Request, name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxCollectionNameLength {
+		respondWithError(response, request, fmt.Sprintf("A collection name has to be 1 to %d characters long", maxCollectionNameLength), nil, http.StatusBadRequest)
+		return "", false
+	}
+	return name, true
+}
+
+// getCollectionForUser gets the logged in user and {CollectionID}. Other people's collections look like they don't exist
+func (config *apiConfig) getCollectionForUser(response http.ResponseWriter, request *http.Request) (uuid.UUID, database.Collection, bool) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return uuid.Nil, database.Collection{}, false
+	}
+
+	collectionID, err := uuid.Parse(request.PathValue("CollectionID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing the UUID of the collection", err, http.StatusBadRequest)
+		return uuid.Nil, database.Collection{}, false
+	}
+
+	collection, err := config.dbQueries.GetCollection(request.Context(), database.GetCollectionParams{
+		ID:     collectionID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(response, request, "Couldn't find that collection", err, http.StatusNotFound)
+		return uuid.Nil, database.Collection{}, false
+	}
+	return userID, collection, true
+}
+
+func (config *apiConfig) createCollectionHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	type IncomingJSON struct {
+		Name string `json:"name"`
+	}
+	incomingjson := IncomingJSON{}
+	if err = json.NewDecoder(request.Body).Decode(&incomingjson); err != nil {
+		respondWithError(response, request, "Something went wrong, required format: {'name': 'NAME'}", err, http.StatusBadRequest)
+		return
+	}
+	name, ok := validateCollectionName(response, request, incomingjson.Name)
+	if !ok {
+		return
+	}
+
+	count, err := config.dbQueries.CountCollections(request.Context(), userID)
+	if err != nil {
+		respondWithError(response, request, "There was an error creating the collection", err, http.StatusInternalServerError)
+		return
+	}
+	if count >= maxCollections {
+		respondWithError(response, request, fmt.Sprintf("You can't have more than %d collections", maxCollections), nil, http.StatusConflict)
+		return
+	}
+
+	collection, err := config.dbQueries.CreateCollection(request.Context(), database.CreateCollectionParams{
+		UserID: userID,
+		Name:   name,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(response, request, "You already have a collection with that name", err, http.StatusConflict)
+			return
+		}
+		respondWithError(response, request, "There was an error creating the collection", err, http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(response, request, Collection{
+		ID:        collection.ID,
+		Name:      collection.Name,
+		CreatedAt: collection.CreatedAt,
+		UpdatedAt: collection.UpdatedAt,
+	}, http.StatusCreated)
+}
+
+func (config *apiConfig) getCollectionsHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	rows, err := config.dbQueries.ListCollections(request.Context(), userID)
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching your collections", err, http.StatusInternalServerError)
+		return
+	}
+
+	collections := []Collection{}
+	for _, row := range rows {
+		collections = append(collections, Collection{
+			ID:         row.Collection.ID,
+			Name:       row.Collection.Name,
+			ChirpCount: row.ChirpCount,
+			CreatedAt:  row.Collection.CreatedAt,
+			UpdatedAt:  row.Collection.UpdatedAt,
+		})
+	}
+	respondWithJSON(response, request, collections, http.StatusOK)
+}
+
+func (config *apiConfig) renameCollectionHandler(response http.ResponseWriter, request *http.Request) {
+	userID, collection, ok := config.getCollectionForUser(response, request)
+	if !ok {
+		return
+	}
+
+	type IncomingJSON struct {
+		Name string `json:"name"`
+	}
+	incomingjson := IncomingJSON{}
+	if err := json.NewDecoder(request.Body).Decode(&incomingjson); err != nil {
+		respondWithError(response, request, "Something went wrong, required format: {'name': 'NAME'}", err, http.StatusBadRequest)
+		return
+	}
+	name, ok := validateCollectionName(response, request, incomingjson.Name)
+	if !ok {
+		return
+	}
+
+	collection, err := config.dbQueries.RenameCollection(request.Context(), database.RenameCollectionParams{
+		ID:     collection.ID,
+		UserID: userID,
+		Name:   name,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(response, request, "You already have a collection with that name", err, http.StatusConflict)
+			return
+		}
+		respondWithError(response, request, "There was an error renaming the collection", err, http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(response, request, Collection{
+		ID:        collection.ID,
+		Name:      collection.Name,
+		CreatedAt: collection.CreatedAt,
+		UpdatedAt: collection.UpdatedAt,
+	}, http.StatusOK)
+}
+
+// deleteCollectionHandler only deletes the collection, the chirps in it aren't touched
+func (config *apiConfig) deleteCollectionHandler(response http.ResponseWriter, request *http.Request) {
+	userID, collection, ok := config.getCollectionForUser(response, request)
+	if !ok {
+		return
+	}
+
+	if _, err := config.dbQueries.DeleteCollection(request.Context(), database.DeleteCollectionParams{
+		ID:     collection.ID,
+		UserID: userID,
+	}); err != nil {
+		respondWithError(response, request, "There was an error deleting the collection", err, http.StatusInternalServerError)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func (config *apiConfig) addToCollectionHandler(response http.ResponseWriter, request *http.Request) {
+	userID, collection, ok := config.getCollectionForUser(response, request)
+	if !ok {
+		return
+	}
+
+	type IncomingJSON struct {
+		ChirpID string `json:"chirp_id"`
+	}
+	incomingjson := IncomingJSON{}
+	if err := json.NewDecoder(request.Body).Decode(&incomingjson); err != nil {
+		respondWithError(response, request, "Something went wrong, required format: {'chirp_id': 'CHIRP_ID'}", err, http.StatusBadRequest)
+		return
+	}
+	chirpID, ok := config.findChirpToSave(response, request, userID, incomingjson.ChirpID)
+	if !ok {
+		return
+	}
+
+	if _, err := config.dbQueries.AddChirpToCollection(request.Context(), database.AddChirpToCollectionParams{
+		CollectionID: collection.ID,
+		ChirpID:      chirpID,
+	}); err != nil {
+		respondWithError(response, request, "There was an error adding the chirp to the collection", err, http.StatusInternalServerError)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func (config *apiConfig) removeFromCollectionHandler(response http.ResponseWriter, request *http.Request) {
+	_, collection, ok := config.getCollectionForUser(response, request)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(request.PathValue("ChirpID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing that UUID", err, http.StatusBadRequest)
+		return
+	}
+
+	rowsAffected, err := config.dbQueries.RemoveChirpFromCollection(request.Context(), database.RemoveChirpFromCollectionParams{
+		CollectionID: collection.ID,
+		ChirpID:      chirpID,
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error removing the chirp from the collection", err, http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected == 0 {
+		respondWithError(response, request, "That chirp isn't in the collection", nil, http.StatusNotFound)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+// getCollectionChirpsHandler lists the chirps in a collection, most recently added first
+func (config *apiConfig) getCollectionChirpsHandler(response http.ResponseWriter, request *http.Request) {
+	userID, collection, ok := config.getCollectionForUser(response, request)
+	if !ok {
+		return
+	}
+
+	limit, beforeCreatedAt, beforeID, ok := parseSavedPage(response, request)
+	if !ok {
+		return
+	}
+
+	rows, err := config.dbQueries.GetCollectionChirps(request.Context(), database.GetCollectionChirpsParams{
+		CollectionID:    collection.ID,
+		UserID:          userID,
+		BeforeCreatedAt: beforeCreatedAt,
+		BeforeID:        beforeID,
+		RowLimit:        int32(limit + 1),
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching the collection", err, http.StatusInternalServerError)
+		return
+	}
+
+	saved := []savedChirp{}
+	for _, row := range rows {
+		saved = append(saved, savedChirp{chirp: row.Chirp, savedAt: row.SavedAt})
+	}
+	config.respondWithSavedChirps(response, request, userID, limit, saved)
+}
+
+// unsaveChirp takes a deleted chirp out of everyone's bookmarks and collections
+func unsaveChirp(ctx context.Context, queries *database.Queries, chirpID uuid.UUID) error {
+	if err := queries.RemoveChirpFromBookmarks(ctx, chirpID); err != nil {
+		return err
+	}
+	return queries.RemoveChirpFromCollections(ctx, chirpID)
+}
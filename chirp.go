@@ -1,24 +1,51 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
-	"sort"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/vilebile17/chirpy/internal/auth"
 	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/pagination"
+	"github.com/vilebile17/chirpy/internal/pubsub"
 )
 
+const maxChirpLength = 140
+
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID             uuid.UUID     `json:"id"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+	Body           string        `json:"body"`
+	UserID         uuid.UUID     `json:"user_id"`
+	Author         *ChirpAuthor  `json:"author,omitempty"`
+	InReplyToID    *uuid.UUID    `json:"in_reply_to_id,omitempty"`
+	Deleted        bool          `json:"deleted,omitempty"`
+	Edited         bool          `json:"edited"`
+	ExpiresAt      *time.Time    `json:"expires_at,omitempty"`
+	ContentWarning string        `json:"content_warning,omitempty"`
+	Sensitive      bool          `json:"sensitive"`
+	Collapsed      bool          `json:"collapsed"`
+	LikeCount      int64         `json:"like_count"`
+	LikedByMe      bool          `json:"liked_by_me"`
+	Bookmarked     bool          `json:"bookmarked"`
+	Pinned         bool          `json:"pinned,omitempty"`
+	QuotedChirp    *Chirp        `json:"quoted_chirp,omitempty"`
+	RechirpOf      *Chirp        `json:"rechirp_of,omitempty"`
+	Entities       []ChirpEntity `json:"entities"`
+	Media          []Media       `json:"media,omitempty"`
+	Poll           *Poll         `json:"poll,omitempty"`
+
+	quotedChirpID uuid.NullUUID
+	rechirpOfID   uuid.NullUUID
 }
 
 func respondWithError(response http.ResponseWriter, _ *http.Request, message string, err error, statusCode int) {
@@ -56,19 +83,16 @@ func respondWithJSON(response http.ResponseWriter, request *http.Request, payloa
 	response.Write(data)
 }
 
-func cleanProfanity(text string) string {
-	words := strings.Split(text, " ")
-	for i, word := range words {
-		if strings.ToLower(word) == "kerfuffle" || word == "sharbert" || word == "fornax" {
-			words[i] = "****"
-		}
-	}
-	return strings.Join(words, " ")
-}
-
 func (config *apiConfig) createChirpHandler(response http.ResponseWriter, request *http.Request) {
 	type IncomingJSON struct {
-		Body string `json:"body"`
+		Body           string   `json:"body"`
+		InReplyToID    string   `json:"in_reply_to_id"`
+		QuotedChirpID  string   `json:"quoted_chirp_id"`
+		MediaIDs       []string `json:"media_ids"`
+		Poll           *NewPoll `json:"poll"`
+		ExpiresIn      *int     `json:"expires_in"`
+		ContentWarning string   `json:"content_warning"`
+		Sensitive      bool     `json:"sensitive"`
 	}
 
 	decoder := json.NewDecoder(request.Body)
@@ -91,67 +115,453 @@ func (config *apiConfig) createChirpHandler(response http.ResponseWriter, reques
 		return
 	}
 
-	maxChirpLength := 140
-	if len(incomingjson.Body) > maxChirpLength {
-		respondWithError(response, request, "Chirp too long", nil, http.StatusBadRequest)
+	mediaIDs, ok := parseMediaIDs(response, request, incomingjson.MediaIDs)
+	if !ok {
 		return
-	} else if len(incomingjson.Body) == 0 {
-		respondWithError(response, request, "Chirp must be atleast one character long", nil, http.StatusBadRequest)
+	}
+
+	if !validateChirpBody(response, request, incomingjson.Body, len(mediaIDs) > 0) {
 		return
 	}
 
-	sqlChirp, err := config.dbQueries.CreateChirp(request.Context(), database.CreateChirpParams{
-		Body:   cleanProfanity(incomingjson.Body),
-		UserID: userID,
-	})
+	var pollOptions []string
+	var pollDuration time.Duration
+	if incomingjson.Poll != nil {
+		if pollOptions, pollDuration, ok = validatePoll(response, request, *incomingjson.Poll); !ok {
+			return
+		}
+	}
+
+	expiresAt, ok := parseExpiresIn(response, request, incomingjson.ExpiresIn)
+	if !ok {
+		return
+	}
+
+	contentWarning := strings.TrimSpace(incomingjson.ContentWarning)
+	if !validateContentWarning(response, request, contentWarning) {
+		return
+	}
+
+	body := incomingjson.Body
+	texts := []*string{&body, &contentWarning}
+	for i := range pollOptions {
+		texts = append(texts, &pollOptions[i])
+	}
+	flagged, err := config.moderateTexts(texts...)
+	if err != nil {
+		respondWithChirpError(response, request, "There was an error checking the chirp", err)
+		return
+	}
+
+	inReplyToID, quotedChirpID, ok := parseChirpTargets(response, request, incomingjson.InReplyToID, incomingjson.QuotedChirpID)
+	if !ok {
+		return
+	}
+	quotedChirpID, err = config.checkChirpTargets(request.Context(), userID, inReplyToID, quotedChirpID)
+	if err != nil {
+		respondWithChirpError(response, request, "There was an error checking the chirps being replied to or quoted", err)
+		return
+	}
+
+	tx, err := config.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(response, request, "There was an error creating the chirp", err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	queries := config.dbQueries.WithTx(tx)
+
+	sqlChirp, mentioned, err := insertChirp(request.Context(), queries, database.CreateChirpParams{
+		Body:           body,
+		UserID:         userID,
+		InReplyToID:    inReplyToID,
+		QuotedChirpID:  quotedChirpID,
+		ExpiresAt:      expiresAt,
+		ContentWarning: contentWarning,
+		Sensitive:      incomingjson.Sensitive,
+	}, mediaIDs)
+	if err != nil {
+		respondWithChirpError(response, request, "There was an error creating the chirp", err)
+		return
+	}
+
+	if pollOptions != nil {
+		if err = createPoll(request.Context(), queries, sqlChirp.ID, pollOptions, pollDuration); err != nil {
+			respondWithError(response, request, "There was an error creating the poll", err, http.StatusInternalServerError)
+			return
+		}
+	}
+	if err = flagChirp(request.Context(), queries, sqlChirp.ID, flagged); err != nil {
+		respondWithError(response, request, "There was an error flagging the chirp for review", err, http.StatusInternalServerError)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		respondWithError(response, request, "There was an error creating the chirp", err, http.StatusInternalServerError)
+		return
+	}
+	config.notifyMentions(request.Context(), sqlChirp, mentioned)
+
+	chirp, err := config.announceChirp(request.Context(), sqlChirp)
 	if err != nil {
-		respondWithError(response, request, "There was an error creating the chirp", err, http.StatusBadRequest)
+		respondWithError(response, request, "There was an error loading the chirp", err, http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(response, request, chirp, http.StatusCreated)
+}
+
+// chirpError is a problem with a new chirp that the user can fix, the message is safe to show them
+type chirpError struct {
+	message string
+	status  int
+}
+
+func (err *chirpError) Error() string {
+	return err.message
+}
+
+// respondWithChirpError uses the message and status of a chirpError, anything else is our fault
+func respondWithChirpError(response http.ResponseWriter, request *http.Request, message string, err error) {
+	if chirpErr := (&chirpError{}); errors.As(err, &chirpErr) {
+		respondWithError(response, request, chirpErr.message, err, chirpErr.status)
 		return
 	}
+	respondWithError(response, request, message, err, http.StatusInternalServerError)
+}
+
+// parseChirpTargets parses in_reply_to_id and quoted_chirp_id, either can be left empty
+func parseChirpTargets(response http.ResponseWriter, request *http.Request, rawInReplyToID, rawQuotedChirpID string) (uuid.NullUUID, uuid.NullUUID, bool) {
+	inReplyToID, quotedChirpID := uuid.NullUUID{}, uuid.NullUUID{}
+	if rawInReplyToID != "" {
+		parentID, err := uuid.Parse(rawInReplyToID)
+		if err != nil {
+			respondWithError(response, request, "There was an error parsing the in_reply_to_id UUID", err, http.StatusBadRequest)
+			return uuid.NullUUID{}, uuid.NullUUID{}, false
+		}
+		inReplyToID = uuid.NullUUID{UUID: parentID, Valid: true}
+	}
+	if rawQuotedChirpID != "" {
+		originalID, err := uuid.Parse(rawQuotedChirpID)
+		if err != nil {
+			respondWithError(response, request, "There was an error parsing the quoted_chirp_id UUID", err, http.StatusBadRequest)
+			return uuid.NullUUID{}, uuid.NullUUID{}, false
+		}
+		quotedChirpID = uuid.NullUUID{UUID: originalID, Valid: true}
+	}
+	return inReplyToID, quotedChirpID, true
+}
+
+// checkChirpTargets makes sure the chirps being replied to and quoted are still around, and that they aren't from
+// someone on either side of a block with the user. Quoting a rechirp quotes the chirp that was rechirped, so the quoted
+// ID that comes back can be different
+func (config *apiConfig) checkChirpTargets(ctx context.Context, userID uuid.UUID, inReplyToID, quotedChirpID uuid.NullUUID) (uuid.NullUUID, error) {
+	viewerID := uuid.NullUUID{UUID: userID, Valid: true}
+	if inReplyToID.Valid {
+		parent, err := config.dbQueries.GetChirpForViewer(ctx, database.GetChirpForViewerParams{ID: inReplyToID.UUID, ViewerID: viewerID})
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.NullUUID{}, &chirpError{"The chirp being replied to wasn't found", http.StatusNotFound}
+		} else if err != nil {
+			return uuid.NullUUID{}, err
+		}
+		if parent.TombstonedAt.Valid {
+			return uuid.NullUUID{}, &chirpError{"You can't reply to a deleted chirp", http.StatusBadRequest}
+		}
+	}
+
+	if quotedChirpID.Valid {
+		original, err := config.dbQueries.GetChirpForViewer(ctx, database.GetChirpForViewerParams{ID: quotedChirpID.UUID, ViewerID: viewerID})
+		if err == nil && original.RechirpOfID.Valid {
+			original, err = config.dbQueries.GetChirpForViewer(ctx, database.GetChirpForViewerParams{ID: original.RechirpOfID.UUID, ViewerID: viewerID})
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.NullUUID{}, &chirpError{"The chirp being quoted wasn't found", http.StatusNotFound}
+		} else if err != nil {
+			return uuid.NullUUID{}, err
+		}
+		if original.TombstonedAt.Valid {
+			return uuid.NullUUID{}, &chirpError{"You can't quote a deleted chirp", http.StatusBadRequest}
+		}
+		quotedChirpID.UUID = original.ID
+	}
+	return quotedChirpID, nil
+}
+
+// insertChirp saves a new chirp and everything that hangs off it. It's shared by createChirpHandler and the scheduled
+// chirp publisher, and has to run inside their transaction. It also returns the mentioned users to pass to
+// notifyMentions after the transaction is committed
+func insertChirp(ctx context.Context, queries *database.Queries, params database.CreateChirpParams, mediaIDs []uuid.UUID) (database.Chirp, []uuid.UUID, error) {
+	chirp, err := queries.CreateChirp(ctx, params)
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	if len(mediaIDs) > 0 {
+		attached, err := queries.AttachMedia(ctx, database.AttachMediaParams{
+			ChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			Ids:     mediaIDs,
+			UserID:  params.UserID,
+		})
+		if err != nil {
+			return database.Chirp{}, nil, fmt.Errorf("attaching the media: %w", err)
+		}
+		// Every ID has to be the user's own upload that isn't on another chirp already
+		if attached != int64(len(mediaIDs)) {
+			return database.Chirp{}, nil, &chirpError{"Some of the media_ids weren't found or are already used", http.StatusBadRequest}
+		}
+	}
+
+	if err = tagChirp(ctx, queries, chirp); err != nil {
+		return database.Chirp{}, nil, fmt.Errorf("saving the hashtags: %w", err)
+	}
+	mentioned, err := mentionUsers(ctx, queries, chirp)
+	if err != nil {
+		return database.Chirp{}, nil, fmt.Errorf("saving the mentions: %w", err)
+	}
+
+	// Fan out on write: copy the chirp into the timelines of the author and everyone following them
+	if err = queries.FanOutChirp(ctx, chirp.ID); err != nil {
+		return database.Chirp{}, nil, fmt.Errorf("adding the chirp to timelines: %w", err)
+	}
+	return chirp, mentioned, nil
+}
+
+// announceChirp loads everything the author would see on their new chirp and tells the streams about it. The streams
+// get the chirp as a logged out user would see it
+func (config *apiConfig) announceChirp(ctx context.Context, sqlChirp database.Chirp) (Chirp, error) {
+	chirp := chirpFromDatabase(sqlChirp)
+	if err := config.hydrateChirps(ctx, uuid.NullUUID{UUID: sqlChirp.UserID, Valid: true}, []*Chirp{&chirp}); err != nil {
+		return Chirp{}, err
+	}
 
-	respondWithJSON(response, request, Chirp{
-		ID:        sqlChirp.ID,
-		CreatedAt: sqlChirp.CreatedAt,
-		UpdatedAt: sqlChirp.UpdatedAt,
-		Body:      sqlChirp.Body,
-		UserID:    sqlChirp.UserID,
-	}, http.StatusCreated)
+	if err := config.publishChirp(ctx, pubsub.EventChirpCreated, sqlChirp); err != nil {
+		log.Printf("Error publishing the new chirp %s: %s\n", chirp.ID, err)
+	}
+	return chirp, nil
+}
+
+// validateChirpBody writes the error response itself when the body is too long, or empty on a chirp without media
+func validateChirpBody(response http.ResponseWriter, request *http.Request, body string, hasMedia bool) bool {
+	if len(body) > maxChirpLength {
+		respondWithError(response, request, "Chirp too long", nil, http.StatusBadRequest)
+		return false
+	} else if len(body) == 0 && !hasMedia {
+		respondWithError(response, request, "Chirp must be atleast one character long", nil, http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func chirpFromDatabase(chirp database.Chirp) Chirp {
+	result := Chirp{
+		ID:        chirp.ID,
+		CreatedAt: chirp.CreatedAt,
+		UpdatedAt: chirp.UpdatedAt,
+		Body:      chirp.Body,
+		UserID:    chirp.UserID,
+		Deleted:   chirp.TombstonedAt.Valid || chirp.DeletedAt.Valid || chirpHasExpired(chirp.ExpiresAt),
+		Edited:    chirp.EditedAt.Valid,
+
+		ContentWarning: chirp.ContentWarning,
+		Sensitive:      chirp.Sensitive,
+
+		quotedChirpID: chirp.QuotedChirpID,
+		rechirpOfID:   chirp.RechirpOfID,
+	}
+	if chirp.InReplyToID.Valid {
+		result.InReplyToID = &chirp.InReplyToID.UUID
+	}
+	if chirp.ExpiresAt.Valid {
+		result.ExpiresAt = &chirp.ExpiresAt.Time
+	}
+	// Chirps in the trash or that have expired still turn up in threads and quotes, but without anything to read
+	if result.Deleted {
+		result.Body = ""
+		result.ContentWarning = ""
+	}
+	return result
 }
 
+// hydrateChirps fills in everything on a Chirp that doesn't live in the chirps table.
+// viewerID is the logged in user (if any) so that fields like liked_by_me can be worked out
+func (config *apiConfig) hydrateChirps(ctx context.Context, viewerID uuid.NullUUID, chirps []*Chirp) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+
+	embedded, err := config.addEmbeddedChirps(ctx, viewerID, chirps)
+	if err != nil {
+		return err
+	}
+	chirps = append(chirps, embedded...)
+
+	if err = config.addAuthors(ctx, chirps); err != nil {
+		return err
+	}
+	if err = config.addEntities(ctx, chirps); err != nil {
+		return err
+	}
+	if err = config.addMedia(ctx, chirps); err != nil {
+		return err
+	}
+	if err = config.addPolls(ctx, viewerID, chirps); err != nil {
+		return err
+	}
+	if err = config.addBookmarks(ctx, viewerID, chirps); err != nil {
+		return err
+	}
+	if err = config.addCollapsed(ctx, viewerID, chirps); err != nil {
+		return err
+	}
+	return config.addLikes(ctx, viewerID, chirps)
+}
+
+// purgeChirp deletes a chirp for good, along with its rechirps. Chirps with replies or quotes are tombstoned instead so
+// that the rest of the thread stays connected
+func purgeChirp(ctx context.Context, queries *database.Queries, chirpID uuid.UUID) error {
+	if err := queries.DeleteRechirpsOf(ctx, uuid.NullUUID{UUID: chirpID, Valid: true}); err != nil {
+		return err
+	}
+
+	hasDependents, err := queries.ChirpHasDependents(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+	if hasDependents {
+		return tombstoneChirp(ctx, queries, chirpID)
+	}
+	return queries.DeleteChirp(ctx, chirpID)
+}
+
+// tombstoneChirp empties a chirp that has to stay around for its replies and quotes. Everything else that pointed at it
+// goes: its media is let go for the cleaner to pick up, and its old versions, saves and pin are deleted so that nothing
+// of the chirp is left to read
+func tombstoneChirp(ctx context.Context, queries *database.Queries, chirpID uuid.UUID) error {
+	if err := queries.DetachChirpMedia(ctx, uuid.NullUUID{UUID: chirpID, Valid: true}); err != nil {
+		return err
+	}
+	if err := queries.DeleteChirpRevisions(ctx, chirpID); err != nil {
+		return err
+	}
+	if err := unsaveChirp(ctx, queries, chirpID); err != nil {
+		return err
+	}
+	if err := queries.UnpinChirpEverywhere(ctx, chirpID); err != nil {
+		return err
+	}
+	return queries.TombstoneChirp(ctx, chirpID)
+}
+
+func chirpPointers(chirps []Chirp) []*Chirp {
+	pointers := make([]*Chirp, 0, len(chirps))
+	for i := range chirps {
+		pointers = append(pointers, &chirps[i])
+	}
+	return pointers
+}
+
+// getAllChirpsHandler pages through the chirps using the ?limit=, ?after= and ?before= query parameters.
+// If there's another page, a Link header with rel="next" points at it
 func (config *apiConfig) getAllChirpsHandler(response http.ResponseWriter, request *http.Request) {
-	var sqlChirps []database.Chirp
-	var err error
-	authorID := request.URL.Query().Get("author_id")
+	query := request.URL.Query()
 
-	if authorID == "" {
-		sqlChirps, err = config.dbQueries.GetAllChirps(request.Context())
-	} else {
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(response, request, err.Error(), err, http.StatusBadRequest)
+		return
+	}
+
+	sortMethod := query.Get("sort")
+	if sortMethod != "" && sortMethod != "asc" && sortMethod != "desc" {
+		respondWithError(response, request, "sort must be either 'asc' or 'desc'", nil, http.StatusBadRequest)
+		return
+	}
+
+	// Chirps from anyone the viewer has blocked (or been blocked by) or muted are filtered out in the query, along with
+	// rechirps of their chirps
+	viewerID := getViewerFromHeader(request.Header, config.secret)
+	params := database.ListChirpsAscendingParams{
+		RowLimit:      int32(limit + 1),
+		ViewerID:      viewerID,
+		HideSensitive: query.Get("hide_sensitive") == "true",
+	}
+	if authorID := query.Get("author_id"); authorID != "" {
 		userID, err := uuid.Parse(authorID)
 		if err != nil {
 			respondWithError(response, request, "There was an error parsing the UUID of the user", err, http.StatusBadRequest)
 			return
 		}
-		sqlChirps, err = config.dbQueries.SearchChirpByAuthor(request.Context(), userID)
+		params.AuthorID = uuid.NullUUID{UUID: userID, Valid: true}
+	}
+	if after := query.Get("after"); after != "" {
+		cursor, err := pagination.DecodeCursor(after)
+		if err != nil {
+			respondWithError(response, request, "The 'after' cursor is invalid", err, http.StatusBadRequest)
+			return
+		}
+		params.AfterCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+	if before := query.Get("before"); before != "" {
+		cursor, err := pagination.DecodeCursor(before)
+		if err != nil {
+			respondWithError(response, request, "The 'before' cursor is invalid", err, http.StatusBadRequest)
+			return
+		}
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
 	}
 
+	var sqlChirps []database.Chirp
+	if sortMethod == "desc" {
+		sqlChirps, err = config.dbQueries.ListChirpsDescending(request.Context(), database.ListChirpsDescendingParams(params))
+	} else {
+		sqlChirps, err = config.dbQueries.ListChirpsAscending(request.Context(), params)
+	}
 	if err != nil {
 		respondWithError(response, request, "There was an error fetching the Chirps", err, http.StatusBadRequest)
 		return
 	}
 
-	chirps := []Chirp{}
-	for _, chirp := range sqlChirps {
-		chirps = append(chirps, Chirp{
-			ID:        chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			Body:      chirp.Body,
-			UserID:    chirp.UserID,
-		})
+	if len(sqlChirps) > limit {
+		sqlChirps = sqlChirps[:limit]
+		last := sqlChirps[len(sqlChirps)-1]
+		next := pagination.EncodeCursor(pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+
+		if sortMethod == "desc" {
+			response.Header().Set("Link", pagination.NextLink(request.URL, "before", next))
+		} else {
+			response.Header().Set("Link", pagination.NextLink(request.URL, "after", next))
+		}
 	}
 
-	if sortMethod := request.URL.Query().Get("sort"); sortMethod == "desc" {
-		sort.Slice(chirps, func(i, j int) bool { return chirps[i].CreatedAt.After(chirps[j].CreatedAt) })
+	// An author's pinned chirps go before everything else on the first page, and are flagged wherever else they show up
+	chirps := []Chirp{}
+	pinned := map[uuid.UUID]bool{}
+	if params.AuthorID.Valid {
+		pinnedChirps, err := config.getPinnedChirps(request.Context(), params.AuthorID.UUID, viewerID)
+		if err != nil {
+			respondWithError(response, request, "There was an error fetching the pinned chirps", err, http.StatusInternalServerError)
+			return
+		}
+		for _, chirp := range pinnedChirps {
+			pinned[chirp.ID] = true
+			if !params.AfterCreatedAt.Valid && !params.BeforeCreatedAt.Valid && !(params.HideSensitive && chirp.Sensitive) {
+				chirps = append(chirps, chirp)
+			}
+		}
+	}
+	pinnedFirst := len(chirps) > 0
+	for _, sqlChirp := range sqlChirps {
+		if pinned[sqlChirp.ID] && pinnedFirst {
+			continue
+		}
+		chirp := chirpFromDatabase(sqlChirp)
+		chirp.Pinned = pinned[chirp.ID]
+		chirps = append(chirps, chirp)
+	}
+	if err = config.hydrateChirps(request.Context(), viewerID, chirpPointers(chirps)); err != nil {
+		respondWithError(response, request, "There was an error loading the Chirps", err, http.StatusInternalServerError)
+		return
 	}
 
 	respondWithJSON(response, request, chirps, http.StatusOK)
@@ -164,20 +574,25 @@ func (config *apiConfig) getChirpHandler(response http.ResponseWriter, request *
 		return
 	}
 
-	chirp, err := config.dbQueries.GetOneChirp(request.Context(), chirpID)
+	// A chirp from someone on either side of a block looks the same as one that doesn't exist
+	viewerID := getViewerFromHeader(request.Header, config.secret)
+	chirp, err := config.dbQueries.GetChirpForViewer(request.Context(), database.GetChirpForViewerParams{
+		ID:       chirpID,
+		ViewerID: viewerID,
+	})
 	if err != nil {
 		respondWithError(response, request, "Chirp not found", err, http.StatusNotFound)
 		response.WriteHeader(404)
 		return
 	}
 
-	respondWithJSON(response, request, Chirp{
-		chirp.ID,
-		chirp.CreatedAt,
-		chirp.UpdatedAt,
-		chirp.Body,
-		chirp.UserID,
-	}, http.StatusOK)
+	result := chirpFromDatabase(chirp)
+	if err = config.hydrateChirps(request.Context(), viewerID, []*Chirp{&result}); err != nil {
+		respondWithError(response, request, "There was an error loading the chirp", err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(response, request, result, http.StatusOK)
 }
 
 func (config *apiConfig) deleteChirpHandler(response http.ResponseWriter, request *http.Request) {
@@ -194,7 +609,7 @@ func (config *apiConfig) deleteChirpHandler(response http.ResponseWriter, reques
 	}
 
 	chirp, err := config.dbQueries.GetOneChirp(request.Context(), chirpID)
-	if err != nil {
+	if err != nil || chirp.TombstonedAt.Valid {
 		respondWithError(response, request, "Chirp not found", err, http.StatusNotFound)
 		return
 	}
@@ -204,9 +619,33 @@ func (config *apiConfig) deleteChirpHandler(response http.ResponseWriter, reques
 		return
 	}
 
-	if err = config.dbQueries.DeleteChirp(request.Context(), chirpID); err != nil {
+	tx, err := config.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(response, request, "There was an error deleting the chirp", err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	queries := config.dbQueries.WithTx(tx)
+
+	// Chirps go to their author's trash (taking their rechirps with them) until they're restored or purged, and come out
+	// of everyone's bookmarks and collections straight away. Rechirps have nothing worth restoring, so they skip it
+	if chirp.RechirpOfID.Valid {
+		err = purgeChirp(request.Context(), queries, chirpID)
+	} else if err = queries.SoftDeleteChirp(request.Context(), chirpID); err == nil {
+		err = unsaveChirp(request.Context(), queries, chirpID)
+	}
+	if err != nil {
 		respondWithError(response, request, "Chirp not able to be deleted for some reason", err, http.StatusBadRequest)
 		return
 	}
+
+	if err = tx.Commit(); err != nil {
+		respondWithError(response, request, "There was an error deleting the chirp", err, http.StatusInternalServerError)
+		return
+	}
+
+	if err = config.publishChirpDeleted(request.Context(), chirp); err != nil {
+		log.Printf("Error publishing the deleted chirp %s: %s\n", chirp.ID, err)
+	}
 	response.WriteHeader(http.StatusNoContent)
 }
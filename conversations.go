@@ -0,0 +1,558 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/pagination"
+	"github.com/vilebile17/chirpy/internal/pubsub"
+)
+
+const (
+	// maxConversationSize includes whoever started the conversation
+	maxConversationSize = 10
+	maxMessageLength    = 1000
+)
+
+type ConversationParticipant struct {
+	UserID     uuid.UUID  `json:"user_id"`
+	Handle     string     `json:"handle,omitempty"`
+	LastReadAt *time.Time `json:"last_read_at"`
+}
+
+type Conversation struct {
+	ID           uuid.UUID                 `json:"id"`
+	CreatedAt    time.Time                 `json:"created_at"`
+	UpdatedAt    time.Time                 `json:"updated_at"`
+	IsGroup      bool                      `json:"is_group"`
+	Participants []ConversationParticipant `json:"participants"`
+	UnreadCount  int64                     `json:"unread_count"`
+	LastMessage  *Message                  `json:"last_message"`
+}
+
+type Message struct {
+	ID             uuid.UUID `json:"id"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	Body           string    `json:"body"`
+	CreatedAt      time.Time `json:"created_at"`
+	// ReadBy is everyone else in the conversation who has read up to this message
+	ReadBy []uuid.UUID `json:"read_by"`
+}
+
+func conversationFromDatabase(conversation database.Conversation) Conversation {
+	return Conversation{
+		ID:           conversation.ID,
+		CreatedAt:    conversation.CreatedAt,
+		UpdatedAt:    conversation.UpdatedAt,
+		IsGroup:      !conversation.DirectKey.Valid,
+		Participants: []ConversationParticipant{},
+	}
+}
+
+func messageFromDatabase(message database.Message, participants []ConversationParticipant) Message {
+	result := Message{
+		ID:             message.ID,
+		ConversationID: message.ConversationID,
+		SenderID:       message.SenderID,
+		Body:           message.Body,
+		CreatedAt:      message.CreatedAt,
+		ReadBy:         []uuid.UUID{},
+	}
+	for _, participant := range participants {
+		if participant.UserID == message.SenderID || participant.LastReadAt == nil {
+			continue
+		}
+		if !participant.LastReadAt.Before(message.CreatedAt) {
+			result.ReadBy = append(result.ReadBy, participant.UserID)
+		}
+	}
+	return result
+}
+
+// directKey is the same for a pair of users whichever way round they are
+func directKey(a, b uuid.UUID) sql.NullString {
+	keys := []string{a.String(), b.String()}
+	slices.Sort(keys)
+	return sql.NullString{String: strings.Join(keys, ":"), Valid: true}
+}
+
+// getParticipants fetches who is in each of the conversations
+func (config *apiConfig) getParticipants(ctx context.Context, conversationIDs []uuid.UUID) (map[uuid.UUID][]ConversationParticipant, error) {
+	sqlParticipants, err := config.dbQueries.GetConversationParticipants(ctx, conversationIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	participants := map[uuid.UUID][]ConversationParticipant{}
+	for _, row := range sqlParticipants {
+		participant := ConversationParticipant{
+			UserID: row.ConversationParticipant.UserID,
+			Handle: row.Handle.String,
+		}
+		if row.ConversationParticipant.LastReadAt.Valid {
+			participant.LastReadAt = &row.ConversationParticipant.LastReadAt.Time
+		}
+		conversationID := row.ConversationParticipant.ConversationID
+		participants[conversationID] = append(participants[conversationID], participant)
+	}
+	return participants, nil
+}
+
+// getConversationForUser checks that the user is part of the {ConversationID} conversation. Anyone else gets a 404 so
+// they can't find out which conversations exist
+func (config *apiConfig) getConversationForUser(response http.ResponseWriter, request *http.Request, userID uuid.UUID) (uuid.UUID, bool) {
+	conversationID, err := uuid.Parse(request.PathValue("ConversationID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing that UUID", err, http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+
+	if _, err = config.dbQueries.GetConversationParticipant(request.Context(), database.GetConversationParticipantParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	}); err != nil {
+		respondWithError(response, request, "Conversation not found", err, http.StatusNotFound)
+		return uuid.Nil, false
+	}
+	return conversationID, true
+}
+
+// createConversationHandler starts a conversation with {"participant_ids": [...]}. With only one other person the
+// existing conversation between the two of you is returned if there is one
+func (config *apiConfig) createConversationHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	type IncomingJSON struct {
+		ParticipantIDs []uuid.UUID `json:"participant_ids"`
+	}
+	incomingjson := IncomingJSON{}
+	if err = json.NewDecoder(request.Body).Decode(&incomingjson); err != nil {
+		respondWithError(response, request, "Something went wrong, required format: {'participant_ids': ['UUID', ...]}", err, http.StatusBadRequest)
+		return
+	}
+
+	recipientIDs := []uuid.UUID{}
+	for _, id := range incomingjson.ParticipantIDs {
+		if id != userID && !slices.Contains(recipientIDs, id) {
+			recipientIDs = append(recipientIDs, id)
+		}
+	}
+	if len(recipientIDs) == 0 {
+		respondWithError(response, request, "A conversation needs someone else in it", nil, http.StatusBadRequest)
+		return
+	}
+	if len(recipientIDs)+1 > maxConversationSize {
+		respondWithError(response, request, "That conversation has too many people in it", nil, http.StatusBadRequest)
+		return
+	}
+
+	recipients, err := config.dbQueries.GetDMRecipients(request.Context(), database.GetDMRecipientsParams{
+		SenderID: userID,
+		UserIds:  recipientIDs,
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error looking up those users", err, http.StatusInternalServerError)
+		return
+	}
+	if len(recipients) != len(recipientIDs) {
+		respondWithError(response, request, "Couldn't find all of those users", nil, http.StatusNotFound)
+		return
+	}
+	for _, recipient := range recipients {
+		if !recipient.Accepts {
+			respondWithError(response, request, "One of those users isn't accepting messages from you", nil, http.StatusForbidden)
+			return
+		}
+	}
+
+	key := sql.NullString{}
+	if len(recipientIDs) == 1 {
+		key = directKey(userID, recipientIDs[0])
+	}
+
+	tx, err := config.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(response, request, "There was an error starting the conversation", err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	queries := config.dbQueries.WithTx(tx)
+
+	status := http.StatusCreated
+	sqlConversation, err := queries.CreateConversation(request.Context(), key)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The two of you already have a conversation
+		status = http.StatusOK
+		sqlConversation, err = queries.GetDirectConversation(request.Context(), key)
+	}
+	if err != nil {
+		respondWithError(response, request, "There was an error starting the conversation", err, http.StatusInternalServerError)
+		return
+	}
+
+	if status == http.StatusCreated {
+		if err = queries.AddConversationParticipants(request.Context(), database.AddConversationParticipantsParams{
+			ConversationID: sqlConversation.ID,
+			UserIds:        append(recipientIDs, userID),
+		}); err != nil {
+			respondWithError(response, request, "There was an error adding people to the conversation", err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		respondWithError(response, request, "There was an error starting the conversation", err, http.StatusInternalServerError)
+		return
+	}
+
+	conversation := conversationFromDatabase(sqlConversation)
+	participants, err := config.getParticipants(request.Context(), []uuid.UUID{conversation.ID})
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching the people in the conversation", err, http.StatusInternalServerError)
+		return
+	}
+	conversation.Participants = participants[conversation.ID]
+	respondWithJSON(response, request, conversation, status)
+}
+
+// getConversationsHandler lists your conversations with the most recently active first. Supports ?limit= and ?before=
+func (config *apiConfig) getConversationsHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	query := request.URL.Query()
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(response, request, err.Error(), err, http.StatusBadRequest)
+		return
+	}
+
+	params := database.ListConversationsParams{
+		UserID:   userID,
+		RowLimit: int32(limit + 1),
+	}
+	if before := query.Get("before"); before != "" {
+		cursor, err := pagination.DecodeCursor(before)
+		if err != nil {
+			respondWithError(response, request, "The 'before' cursor is invalid", err, http.StatusBadRequest)
+			return
+		}
+		params.BeforeUpdatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	rows, err := config.dbQueries.ListConversations(request.Context(), params)
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching your conversations", err, http.StatusInternalServerError)
+		return
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		// Conversations are ordered by when they were last active so that goes in the cursor
+		last := rows[len(rows)-1].Conversation
+		next := pagination.EncodeCursor(pagination.Cursor{CreatedAt: last.UpdatedAt, ID: last.ID})
+		response.Header().Set("Link", pagination.NextLink(request.URL, "before", next))
+	}
+
+	conversations := []Conversation{}
+	ids := []uuid.UUID{}
+	for _, row := range rows {
+		conversation := conversationFromDatabase(row.Conversation)
+		conversation.UnreadCount = row.UnreadCount
+		conversations = append(conversations, conversation)
+		ids = append(ids, conversation.ID)
+	}
+
+	participants, err := config.getParticipants(request.Context(), ids)
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching the people in your conversations", err, http.StatusInternalServerError)
+		return
+	}
+	latestMessages, err := config.dbQueries.GetLatestMessages(request.Context(), database.GetLatestMessagesParams{
+		UserID:          userID,
+		ConversationIds: ids,
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching the latest messages", err, http.StatusInternalServerError)
+		return
+	}
+	latest := map[uuid.UUID]database.Message{}
+	for _, message := range latestMessages {
+		latest[message.ConversationID] = message
+	}
+
+	for i := range conversations {
+		conversations[i].Participants = participants[conversations[i].ID]
+		if message, ok := latest[conversations[i].ID]; ok {
+			lastMessage := messageFromDatabase(message, conversations[i].Participants)
+			conversations[i].LastMessage = &lastMessage
+		}
+	}
+	respondWithJSON(response, request, conversations, http.StatusOK)
+}
+
+// getMessagesHandler lists the newest messages first. Supports ?limit= and ?before=
+func (config *apiConfig) getMessagesHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+	conversationID, ok := config.getConversationForUser(response, request, userID)
+	if !ok {
+		return
+	}
+
+	query := request.URL.Query()
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(response, request, err.Error(), err, http.StatusBadRequest)
+		return
+	}
+
+	params := database.ListMessagesParams{
+		UserID:         userID,
+		ConversationID: conversationID,
+		RowLimit:       int32(limit + 1),
+	}
+	if before := query.Get("before"); before != "" {
+		cursor, err := pagination.DecodeCursor(before)
+		if err != nil {
+			respondWithError(response, request, "The 'before' cursor is invalid", err, http.StatusBadRequest)
+			return
+		}
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	sqlMessages, err := config.dbQueries.ListMessages(request.Context(), params)
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching the messages", err, http.StatusInternalServerError)
+		return
+	}
+
+	if len(sqlMessages) > limit {
+		sqlMessages = sqlMessages[:limit]
+		last := sqlMessages[len(sqlMessages)-1]
+		next := pagination.EncodeCursor(pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		response.Header().Set("Link", pagination.NextLink(request.URL, "before", next))
+	}
+
+	participants, err := config.getParticipants(request.Context(), []uuid.UUID{conversationID})
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching the people in the conversation", err, http.StatusInternalServerError)
+		return
+	}
+
+	messages := []Message{}
+	for _, message := range sqlMessages {
+		messages = append(messages, messageFromDatabase(message, participants[conversationID]))
+	}
+	respondWithJSON(response, request, messages, http.StatusOK)
+}
+
+func (config *apiConfig) sendMessageHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+	conversationID, ok := config.getConversationForUser(response, request, userID)
+	if !ok {
+		return
+	}
+
+	type IncomingJSON struct {
+		Body string `json:"body"`
+	}
+	incomingjson := IncomingJSON{}
+	if err = json.NewDecoder(request.Body).Decode(&incomingjson); err != nil {
+		respondWithError(response, request, "Something went wrong, required format: {'body': 'MESSAGE'}", err, http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(incomingjson.Body) == "" {
+		respondWithError(response, request, "A message can't be empty", nil, http.StatusBadRequest)
+		return
+	}
+	if len(incomingjson.Body) > maxMessageLength {
+		respondWithError(response, request, "Message is too long", nil, http.StatusBadRequest)
+		return
+	}
+
+	participants, err := config.getParticipants(request.Context(), []uuid.UUID{conversationID})
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching the people in the conversation", err, http.StatusInternalServerError)
+		return
+	}
+	// A block stops a one-to-one conversation, group conversations carry on
+	if len(participants[conversationID]) == 2 {
+		for _, participant := range participants[conversationID] {
+			if participant.UserID == userID {
+				continue
+			}
+			blocked, err := config.dbQueries.IsBlockedEitherWay(request.Context(), database.IsBlockedEitherWayParams{
+				BlockerID: userID,
+				BlockedID: participant.UserID,
+			})
+			if err != nil {
+				respondWithError(response, request, "There was an error sending the message", err, http.StatusInternalServerError)
+				return
+			}
+			if blocked {
+				respondWithError(response, request, "That user isn't accepting messages from you", nil, http.StatusForbidden)
+				return
+			}
+		}
+	}
+
+	tx, err := config.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(response, request, "There was an error sending the message", err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	queries := config.dbQueries.WithTx(tx)
+
+	sqlMessage, err := queries.CreateMessage(request.Context(), database.CreateMessageParams{
+		ConversationID: conversationID,
+		SenderID:       userID,
+		Body:           incomingjson.Body,
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error sending the message", err, http.StatusInternalServerError)
+		return
+	}
+	if err = queries.TouchConversation(request.Context(), conversationID); err != nil {
+		respondWithError(response, request, "There was an error sending the message", err, http.StatusInternalServerError)
+		return
+	}
+	// You've obviously read everything up to your own message
+	if err = queries.MarkConversationRead(request.Context(), database.MarkConversationReadParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	}); err != nil {
+		respondWithError(response, request, "There was an error sending the message", err, http.StatusInternalServerError)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		respondWithError(response, request, "There was an error sending the message", err, http.StatusInternalServerError)
+		return
+	}
+
+	message := messageFromDatabase(sqlMessage, participants[conversationID])
+
+	// Let everyone else's live connections know
+	if data, err := json.Marshal(message); err != nil {
+		log.Printf("Error encoding the message for the live connections: %s\n", err)
+	} else {
+		for _, participant := range participants[conversationID] {
+			if participant.UserID == userID {
+				continue
+			}
+			config.hub.Publish(pubsub.Event{
+				Type:        pubsub.EventMessageCreated,
+				RecipientID: participant.UserID,
+				Data:        data,
+			})
+		}
+	}
+
+	respondWithJSON(response, request, message, http.StatusCreated)
+}
+
+// readConversationHandler is a read receipt for everything in the conversation so far
+func (config *apiConfig) readConversationHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+	conversationID, ok := config.getConversationForUser(response, request, userID)
+	if !ok {
+		return
+	}
+
+	if err = config.dbQueries.MarkConversationRead(request.Context(), database.MarkConversationReadParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	}); err != nil {
+		respondWithError(response, request, "There was an error marking the conversation as read", err, http.StatusInternalServerError)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+// deleteConversationHandler only deletes the conversation for you. It comes back (without the old messages) if someone sends a new one
+func (config *apiConfig) deleteConversationHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+	conversationID, ok := config.getConversationForUser(response, request, userID)
+	if !ok {
+		return
+	}
+
+	if err = config.dbQueries.ClearConversation(request.Context(), database.ClearConversationParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	}); err != nil {
+		respondWithError(response, request, "There was an error deleting the conversation", err, http.StatusInternalServerError)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+// deleteMessageHandler hides a message from you, everyone else in the conversation can still see it
+func (config *apiConfig) deleteMessageHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+	conversationID, ok := config.getConversationForUser(response, request, userID)
+	if !ok {
+		return
+	}
+
+	messageID, err := uuid.Parse(request.PathValue("MessageID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing that UUID", err, http.StatusBadRequest)
+		return
+	}
+
+	rowsAffected, err := config.dbQueries.DeleteMessageForUser(request.Context(), database.DeleteMessageForUserParams{
+		UserID:         userID,
+		MessageID:      messageID,
+		ConversationID: conversationID,
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error deleting the message", err, http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected == 0 {
+		respondWithError(response, request, "Message not found", nil, http.StatusNotFound)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
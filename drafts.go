@@ -0,0 +1,370 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+)
+
+const (
+	maxDrafts = 100
+	// maxScheduleAhead is how far in the future a chirp can be scheduled
+	maxScheduleAhead = 365 * 24 * time.Hour
+	// scheduledChirpInterval is how often the publisher looks for chirps that are due
+	scheduledChirpInterval = 15 * time.Second
+)
+
+// Draft is a chirp that hasn't been posted yet. Scheduled chirps are drafts with a publish_at
+type Draft struct {
+	ID            uuid.UUID   `json:"id"`
+	Body          string      `json:"body"`
+	InReplyToID   *uuid.UUID  `json:"in_reply_to_id,omitempty"`
+	QuotedChirpID *uuid.UUID  `json:"quoted_chirp_id,omitempty"`
+	MediaIDs      []uuid.UUID `json:"media_ids"`
+	PublishAt     *time.Time  `json:"publish_at,omitempty"`
+	// LastError is why a scheduled chirp couldn't be published and was turned back into a draft
+	LastError string    `json:"last_error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func draftFromDatabase(draft database.Draft) Draft {
+	result := Draft{
+		ID:        draft.ID,
+		Body:      draft.Body,
+		MediaIDs:  draft.MediaIds,
+		LastError: draft.LastError,
+		CreatedAt: draft.CreatedAt,
+		UpdatedAt: draft.UpdatedAt,
+	}
+	if draft.InReplyToID.Valid {
+		result.InReplyToID = &draft.InReplyToID.UUID
+	}
+	if draft.QuotedChirpID.Valid {
+		result.QuotedChirpID = &draft.QuotedChirpID.UUID
+	}
+	if draft.PublishAt.Valid {
+		result.PublishAt = &draft.PublishAt.Time
+	}
+	if result.MediaIDs == nil {
+		result.MediaIDs = []uuid.UUID{}
+	}
+	return result
+}
+
+// parseDraft reads and checks the body of a create or update request, writing the error response itself.
+// It's checked the same way as a new chirp so that a scheduled chirp is unlikely to fail when it goes out
+func (config *apiConfig) parseDraft(response http.ResponseWriter, request *http.Request, userID uuid.UUID, scheduled bool) (database.CreateDraftParams, bool) {
+	type IncomingJSON struct {
+		Body          string     `json:"body"`
+		InReplyToID   string     `json:"in_reply_to_id"`
+		QuotedChirpID string     `json:"quoted_chirp_id"`
+		MediaIDs      []string   `json:"media_ids"`
+		PublishAt     *time.Time `json:"publish_at"`
+	}
+	incomingjson := IncomingJSON{}
+	if err := json.NewDecoder(request.Body).Decode(&incomingjson); err != nil {
+		respondWithError(response, request, "Something went wrong, required format {'body':'BODY', 'publish_at':'RFC3339 TIME' (only for scheduled chirps)}", err, http.StatusBadRequest)
+		return database.CreateDraftParams{}, false
+	}
+
+	params := database.CreateDraftParams{UserID: userID, Body: incomingjson.Body}
+	if scheduled {
+		if incomingjson.PublishAt == nil {
+			respondWithError(response, request, "publish_at is required for a scheduled chirp", nil, http.StatusBadRequest)
+			return database.CreateDraftParams{}, false
+		}
+		publishAt := incomingjson.PublishAt.UTC()
+		if now := time.Now().UTC(); !publishAt.After(now) || publishAt.After(now.Add(maxScheduleAhead)) {
+			respondWithError(response, request, "publish_at has to be in the future, and less than a year away", nil, http.StatusBadRequest)
+			return database.CreateDraftParams{}, false
+		}
+		params.PublishAt = sql.NullTime{Time: publishAt, Valid: true}
+	} else if incomingjson.PublishAt != nil {
+		respondWithError(response, request, "Drafts can't have a publish_at, use /api/scheduled to schedule a chirp", nil, http.StatusBadRequest)
+		return database.CreateDraftParams{}, false
+	}
+
+	mediaIDs, ok := parseMediaIDs(response, request, incomingjson.MediaIDs)
+	if !ok {
+		return database.CreateDraftParams{}, false
+	}
+	if !validateChirpBody(response, request, incomingjson.Body, len(mediaIDs) > 0) {
+		return database.CreateDraftParams{}, false
+	}
+	if len(mediaIDs) > 0 {
+		usable, err := config.dbQueries.CountUsableMedia(request.Context(), database.CountUsableMediaParams{Ids: mediaIDs, UserID: userID})
+		if err != nil {
+			respondWithError(response, request, "There was an error checking the media", err, http.StatusInternalServerError)
+			return database.CreateDraftParams{}, false
+		}
+		if usable != int64(len(mediaIDs)) {
+			respondWithError(response, request, "Some of the media_ids weren't found or are already used", nil, http.StatusBadRequest)
+			return database.CreateDraftParams{}, false
+		}
+	}
+	params.MediaIds = mediaIDs
+
+	inReplyToID, quotedChirpID, ok := parseChirpTargets(response, request, incomingjson.InReplyToID, incomingjson.QuotedChirpID)
+	if !ok {
+		return database.CreateDraftParams{}, false
+	}
+	quotedChirpID, err := config.checkChirpTargets(request.Context(), userID, inReplyToID, quotedChirpID)
+	if err != nil {
+		respondWithChirpError(response, request, "There was an error checking the chirps being replied to or quoted", err)
+		return database.CreateDraftParams{}, false
+	}
+	params.InReplyToID = inReplyToID
+	params.QuotedChirpID = quotedChirpID
+	return params, true
+}
+
+// createDraftHandler makes the handlers for POST /api/drafts and POST /api/scheduled
+func (config *apiConfig) createDraftHandler(scheduled bool) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		_, userID, err := getJWTFromHeader(request.Header, config.secret)
+		if err != nil {
+			respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+			return
+		}
+
+		params, ok := config.parseDraft(response, request, userID, scheduled)
+		if !ok {
+			return
+		}
+
+		count, err := config.dbQueries.CountDrafts(request.Context(), userID)
+		if err != nil {
+			respondWithError(response, request, "There was an error saving the draft", err, http.StatusInternalServerError)
+			return
+		}
+		if count >= maxDrafts {
+			respondWithError(response, request, fmt.Sprintf("You can't have more than %d drafts and scheduled chirps", maxDrafts), nil, http.StatusConflict)
+			return
+		}
+
+		draft, err := config.dbQueries.CreateDraft(request.Context(), params)
+		if err != nil {
+			respondWithError(response, request, "There was an error saving the draft", err, http.StatusInternalServerError)
+			return
+		}
+		respondWithJSON(response, request, draftFromDatabase(draft), http.StatusCreated)
+	}
+}
+
+// listDraftsHandler makes the handlers for GET /api/drafts (most recently changed first) and GET /api/scheduled
+// (next to go out first)
+func (config *apiConfig) listDraftsHandler(scheduled bool) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		_, userID, err := getJWTFromHeader(request.Header, config.secret)
+		if err != nil {
+			respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+			return
+		}
+
+		rows, err := config.dbQueries.ListDrafts(request.Context(), database.ListDraftsParams{UserID: userID, Scheduled: scheduled})
+		if err != nil {
+			respondWithError(response, request, "There was an error fetching your drafts", err, http.StatusInternalServerError)
+			return
+		}
+
+		drafts := []Draft{}
+		for _, row := range rows {
+			drafts = append(drafts, draftFromDatabase(row))
+		}
+		respondWithJSON(response, request, drafts, http.StatusOK)
+	}
+}
+
+// updateDraftHandler makes the handlers for PUT /api/drafts/{DraftID} and PUT /api/scheduled/{DraftID}.
+// The whole draft is replaced, which also clears last_error
+func (config *apiConfig) updateDraftHandler(scheduled bool) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		_, userID, err := getJWTFromHeader(request.Header, config.secret)
+		if err != nil {
+			respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+			return
+		}
+
+		draftID, err := uuid.Parse(request.PathValue("DraftID"))
+		if err != nil {
+			respondWithError(response, request, "There was an error parsing the UUID of the draft", err, http.StatusBadRequest)
+			return
+		}
+
+		params, ok := config.parseDraft(response, request, userID, scheduled)
+		if !ok {
+			return
+		}
+
+		draft, err := config.dbQueries.UpdateDraft(request.Context(), database.UpdateDraftParams{
+			Body:          params.Body,
+			InReplyToID:   params.InReplyToID,
+			QuotedChirpID: params.QuotedChirpID,
+			MediaIds:      params.MediaIds,
+			PublishAt:     params.PublishAt,
+			ID:            draftID,
+			UserID:        userID,
+			Scheduled:     scheduled,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(response, request, "Couldn't find that draft", err, http.StatusNotFound)
+			return
+		} else if err != nil {
+			respondWithError(response, request, "There was an error saving the draft", err, http.StatusInternalServerError)
+			return
+		}
+		respondWithJSON(response, request, draftFromDatabase(draft), http.StatusOK)
+	}
+}
+
+// deleteDraftHandler makes the handlers for DELETE /api/drafts/{DraftID} and DELETE /api/scheduled/{DraftID}, which
+// cancels the scheduled chirp
+func (config *apiConfig) deleteDraftHandler(scheduled bool) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		_, userID, err := getJWTFromHeader(request.Header, config.secret)
+		if err != nil {
+			respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+			return
+		}
+
+		draftID, err := uuid.Parse(request.PathValue("DraftID"))
+		if err != nil {
+			respondWithError(response, request, "There was an error parsing the UUID of the draft", err, http.StatusBadRequest)
+			return
+		}
+
+		rowsAffected, err := config.dbQueries.DeleteDraft(request.Context(), database.DeleteDraftParams{
+			ID:        draftID,
+			UserID:    userID,
+			Scheduled: scheduled,
+		})
+		if err != nil {
+			respondWithError(response, request, "There was an error deleting the draft", err, http.StatusInternalServerError)
+			return
+		}
+		if rowsAffected == 0 {
+			respondWithError(response, request, "Couldn't find that draft", nil, http.StatusNotFound)
+			return
+		}
+		response.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// scheduledChirpPublisher posts scheduled chirps once they're due. Every instance of the server runs one, so each
+// draft is locked while it's published (and skipped by the others) and deleted in the same transaction as the chirp
+// is created. That way a chirp only ever goes out once, even if a server dies halfway through
+func (config *apiConfig) scheduledChirpPublisher(ctx context.Context) {
+	ticker := time.NewTicker(scheduledChirpInterval)
+	defer ticker.Stop()
+
+	for {
+		skipped := []uuid.UUID{}
+		for {
+			draftID, err := config.publishDueDraft(ctx, skipped)
+			if err != nil {
+				log.Printf("Error publishing a scheduled chirp: %s\n", err)
+				// Leave the draft until the next tick so that one that keeps failing doesn't hold up the rest
+				if !draftID.Valid {
+					break
+				}
+				skipped = append(skipped, draftID.UUID)
+				continue
+			}
+			if !draftID.Valid {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishDueDraft publishes the next scheduled chirp that's due, other than the skipped ones. It returns the ID of the
+// draft it claimed, which isn't valid when there was nothing to do. A chirp that can't go out anymore (like a reply to
+// a deleted chirp) is turned back into a draft and the user is told
+func (config *apiConfig) publishDueDraft(ctx context.Context, skipped []uuid.UUID) (uuid.NullUUID, error) {
+	tx, err := config.db.BeginTx(ctx, nil)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	defer tx.Rollback()
+	queries := config.dbQueries.WithTx(tx)
+
+	draft, err := queries.ClaimDueDraft(ctx, skipped)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.NullUUID{}, nil
+	} else if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	claimed := uuid.NullUUID{UUID: draft.ID, Valid: true}
+
+	quotedChirpID, err := config.checkChirpTargets(ctx, draft.UserID, draft.InReplyToID, draft.QuotedChirpID)
+	if err == nil && len(draft.MediaIds) > 0 {
+		var usable int64
+		usable, err = queries.CountUsableMedia(ctx, database.CountUsableMediaParams{Ids: draft.MediaIds, UserID: draft.UserID})
+		if err == nil && usable != int64(len(draft.MediaIds)) {
+			err = &chirpError{"Some of the media was deleted", http.StatusBadRequest}
+		}
+	}
+	// The banned words might have changed since the chirp was scheduled
+	body := draft.Body
+	var flagged []string
+	if err == nil {
+		flagged, err = config.moderateTexts(&body)
+	}
+	if chirpErr := (&chirpError{}); errors.As(err, &chirpErr) {
+		if err = queries.FailScheduledDraft(ctx, database.FailScheduledDraftParams{ID: draft.ID, LastError: chirpErr.message}); err != nil {
+			return claimed, err
+		}
+		if err = tx.Commit(); err != nil {
+			return claimed, err
+		}
+		if err = config.emitNotification(ctx, draft.UserID, NotificationScheduledChirpFailed, map[string]string{
+			"draft_id": draft.ID.String(),
+			"error":    chirpErr.message,
+		}); err != nil {
+			log.Printf("Error sending a notification: %s\n", err)
+		}
+		return claimed, nil
+	} else if err != nil {
+		return claimed, err
+	}
+
+	sqlChirp, mentioned, err := insertChirp(ctx, queries, database.CreateChirpParams{
+		Body:          body,
+		UserID:        draft.UserID,
+		InReplyToID:   draft.InReplyToID,
+		QuotedChirpID: quotedChirpID,
+	}, draft.MediaIds)
+	if err != nil {
+		return claimed, fmt.Errorf("draft %s: %w", draft.ID, err)
+	}
+	if err = flagChirp(ctx, queries, sqlChirp.ID, flagged); err != nil {
+		return claimed, err
+	}
+	if err = queries.RemovePublishedDraft(ctx, draft.ID); err != nil {
+		return claimed, err
+	}
+	if err = tx.Commit(); err != nil {
+		return claimed, err
+	}
+	config.notifyMentions(ctx, sqlChirp, mentioned)
+
+	if _, err = config.announceChirp(ctx, sqlChirp); err != nil {
+		log.Printf("Error announcing the scheduled chirp %s: %s\n", sqlChirp.ID, err)
+	}
+	return claimed, nil
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/entities"
+	"github.com/vilebile17/chirpy/internal/pubsub"
+)
+
+// ChirpRevision is a body that a chirp had before it was edited
+type ChirpRevision struct {
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+// editChirpHandler changes the body of a chirp, as long as it's still inside the edit window.
+// The old body is kept in chirp_revisions so anyone can see what was changed
+func (config *apiConfig) editChirpHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(request.PathValue("ChirpID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing that UUID", err, http.StatusBadRequest)
+		return
+	}
+
+	type IncomingJSON struct {
+		Body string `json:"body"`
+	}
+	incomingjson := IncomingJSON{}
+	if err = json.NewDecoder(request.Body).Decode(&incomingjson); err != nil {
+		respondWithError(response, request, "Something went wrong, required format {'body':'BODY'}", err, http.StatusBadRequest)
+		return
+	}
+
+	chirp, err := config.dbQueries.GetOneChirp(request.Context(), chirpID)
+	if err != nil {
+		respondWithError(response, request, "Chirp not found", err, http.StatusNotFound)
+		return
+	}
+	if chirp.UserID != userID {
+		respondWithError(response, request, "You can't edit this chirp, incorrect JWT token", nil, http.StatusForbidden)
+		return
+	}
+	if chirp.TombstonedAt.Valid {
+		respondWithError(response, request, "You can't edit a deleted chirp", nil, http.StatusBadRequest)
+		return
+	}
+	if chirp.RechirpOfID.Valid {
+		respondWithError(response, request, "Rechirps can't be edited", nil, http.StatusBadRequest)
+		return
+	}
+
+	user, err := config.dbQueries.GetUserByID(request.Context(), userID)
+	if err != nil {
+		respondWithError(response, request, "Couldn't find the user...", err, http.StatusNotFound)
+		return
+	}
+	window := config.editWindow
+	if user.IsChirpyRed {
+		window = config.redEditWindow
+	}
+	if time.Now().UTC().After(chirp.CreatedAt.Add(window)) {
+		respondWithError(response, request, fmt.Sprintf("Chirps can only be edited for %s after they're posted", window), nil, http.StatusForbidden)
+		return
+	}
+
+	media, err := config.dbQueries.GetMediaForChirps(request.Context(), []uuid.UUID{chirpID})
+	if err != nil {
+		respondWithError(response, request, "There was an error loading the chirp", err, http.StatusInternalServerError)
+		return
+	}
+	if !validateChirpBody(response, request, incomingjson.Body, len(media) > 0) {
+		return
+	}
+
+	body := incomingjson.Body
+	flagged, err := config.moderateTexts(&body)
+	if err != nil {
+		respondWithChirpError(response, request, "There was an error checking the chirp", err)
+		return
+	}
+	// Saving the same body again doesn't count as an edit
+	changed := body != chirp.Body
+	var mentioned []uuid.UUID
+	if changed {
+		tx, err := config.db.BeginTx(request.Context(), nil)
+		if err != nil {
+			respondWithError(response, request, "There was an error editing the chirp", err, http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+		queries := config.dbQueries.WithTx(tx)
+
+		if err = queries.SaveChirpRevision(request.Context(), chirpID); err != nil {
+			respondWithError(response, request, "There was an error saving the old version of the chirp", err, http.StatusInternalServerError)
+			return
+		}
+		chirp, err = queries.EditChirp(request.Context(), database.EditChirpParams{ID: chirpID, Body: body})
+		// Nothing is updated if the chirp expired since it was looked up
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(response, request, "You can't edit a chirp that has expired", err, http.StatusForbidden)
+			return
+		} else if err != nil {
+			respondWithError(response, request, "There was an error editing the chirp", err, http.StatusInternalServerError)
+			return
+		}
+
+		// The hashtags are worked out again from scratch, but mentions that are still in the body are kept so that they
+		// keep pointing at whoever had the handle when it was first written
+		if err = queries.UntagChirp(request.Context(), chirpID); err == nil {
+			err = tagChirp(request.Context(), queries, chirp)
+		}
+		if err != nil {
+			respondWithError(response, request, "There was an error saving the hashtags of the chirp", err, http.StatusInternalServerError)
+			return
+		}
+		if err = queries.RemoveStaleMentions(request.Context(), database.RemoveStaleMentionsParams{
+			ChirpID: chirpID,
+			Handles: entities.Mentions(body),
+		}); err == nil {
+			mentioned, err = mentionUsers(request.Context(), queries, chirp)
+		}
+		if err != nil {
+			respondWithError(response, request, "There was an error saving the mentions of the chirp", err, http.StatusInternalServerError)
+			return
+		}
+		if err = flagChirp(request.Context(), queries, chirpID, flagged); err != nil {
+			respondWithError(response, request, "There was an error flagging the chirp for review", err, http.StatusInternalServerError)
+			return
+		}
+
+		if err = tx.Commit(); err != nil {
+			respondWithError(response, request, "There was an error editing the chirp", err, http.StatusInternalServerError)
+			return
+		}
+		config.notifyMentions(request.Context(), chirp, mentioned)
+	}
+
+	result := chirpFromDatabase(chirp)
+	if err = config.hydrateChirps(request.Context(), uuid.NullUUID{UUID: userID, Valid: true}, []*Chirp{&result}); err != nil {
+		respondWithError(response, request, "There was an error loading the chirp", err, http.StatusInternalServerError)
+		return
+	}
+
+	if changed {
+		if err = config.publishChirp(request.Context(), pubsub.EventChirpUpdated, chirp); err != nil {
+			log.Printf("Error publishing the edited chirp %s: %s\n", result.ID, err)
+		}
+	}
+	respondWithJSON(response, request, result, http.StatusOK)
+}
+
+// getRevisionsHandler lists the old versions of a chirp, newest first. It's visible to whoever can see the chirp
+func (config *apiConfig) getRevisionsHandler(response http.ResponseWriter, request *http.Request) {
+	chirpID, err := uuid.Parse(request.PathValue("ChirpID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing that UUID", err, http.StatusBadRequest)
+		return
+	}
+
+	if _, err = config.dbQueries.GetChirpForViewer(request.Context(), database.GetChirpForViewerParams{
+		ID:       chirpID,
+		ViewerID: getViewerFromHeader(request.Header, config.secret),
+	}); err != nil {
+		respondWithError(response, request, "Chirp not found", err, http.StatusNotFound)
+		return
+	}
+
+	rows, err := config.dbQueries.GetChirpRevisions(request.Context(), chirpID)
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching the revisions", err, http.StatusInternalServerError)
+		return
+	}
+
+	revisions := []ChirpRevision{}
+	for _, row := range rows {
+		revisions = append(revisions, ChirpRevision{Body: row.Body, CreatedAt: row.CreatedAt, ReplacedAt: row.ReplacedAt})
+	}
+	respondWithJSON(response, request, revisions, http.StatusOK)
+}
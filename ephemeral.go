@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	minChirpLifetime = time.Hour
+	maxChirpLifetime = 7 * 24 * time.Hour
+	// expiredChirpInterval is how often the sweeper looks for chirps that have self-destructed. They're hidden as soon
+	// as they expire, so this only decides how long they stay in the database
+	expiredChirpInterval = time.Minute
+)
+
+// parseExpiresIn turns expires_in (in seconds) into when the chirp expires. Chirps without it last forever.
+// It writes the error response itself when the lifetime isn't allowed
+func parseExpiresIn(response http.ResponseWriter, request *http.Request, expiresIn *int) (sql.NullTime, bool) {
+	if expiresIn == nil {
+		return sql.NullTime{}, true
+	}
+
+	lifetime := time.Duration(*expiresIn) * time.Second
+	if lifetime < minChirpLifetime || lifetime > maxChirpLifetime {
+		respondWithError(response, request, fmt.Sprintf("expires_in has to be between %d and %d seconds", int(minChirpLifetime.Seconds()), int(maxChirpLifetime.Seconds())), nil, http.StatusBadRequest)
+		return sql.NullTime{}, false
+	}
+	return sql.NullTime{Time: time.Now().UTC().Add(lifetime), Valid: true}, true
+}
+
+// chirpHasExpired is true once an ephemeral chirp has self-destructed, even if the sweeper hasn't got to it yet
+func chirpHasExpired(expiresAt sql.NullTime) bool {
+	return expiresAt.Valid && !time.Now().UTC().Before(expiresAt.Time)
+}
+
+// expiredChirpSweeper deletes ephemeral chirps once they've expired. Each chirp is locked while it's deleted, the same
+// way as in the trash purger
+func (config *apiConfig) expiredChirpSweeper(ctx context.Context) {
+	ticker := time.NewTicker(expiredChirpInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			swept, err := config.sweepExpiredChirp(ctx)
+			if err != nil {
+				log.Printf("Error deleting an expired chirp: %s\n", err)
+				break
+			}
+			if !swept {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweepExpiredChirp deletes the chirp that expired the longest ago and tells the streams it has gone. It returns false
+// when there was nothing to do
+func (config *apiConfig) sweepExpiredChirp(ctx context.Context) (bool, error) {
+	tx, err := config.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	queries := config.dbQueries.WithTx(tx)
+
+	chirp, err := queries.ClaimExpiredChirp(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	// Like any other purged chirp, one with replies or quotes is tombstoned rather than deleted
+	if err = purgeChirp(ctx, queries, chirp.ID); err != nil {
+		return false, err
+	}
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+
+	if err = config.publishChirpDeleted(ctx, chirp); err != nil {
+		log.Printf("Error publishing the expired chirp %s: %s\n", chirp.ID, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/pagination"
+)
+
+type FollowEntry struct {
+	UserID     uuid.UUID `json:"user_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type FollowList struct {
+	Count int64         `json:"count"`
+	Users []FollowEntry `json:"users"`
+}
+
+func (config *apiConfig) followHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	followeeID, err := uuid.Parse(request.PathValue("UserID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing the UUID of the user", err, http.StatusBadRequest)
+		return
+	}
+	if followeeID == userID {
+		respondWithError(response, request, "You can't follow yourself", nil, http.StatusBadRequest)
+		return
+	}
+	if _, err = config.dbQueries.GetUserByID(request.Context(), followeeID); err != nil {
+		respondWithError(response, request, "Couldn't find the user...", err, http.StatusNotFound)
+		return
+	}
+	blocked, err := config.dbQueries.IsBlockedEitherWay(request.Context(), database.IsBlockedEitherWayParams{
+		BlockerID: userID,
+		BlockedID: followeeID,
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error following the user", err, http.StatusInternalServerError)
+		return
+	}
+	if blocked {
+		respondWithError(response, request, "You can't follow that user", nil, http.StatusForbidden)
+		return
+	}
+
+	tx, err := config.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(response, request, "There was an error following the user", err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	queries := config.dbQueries.WithTx(tx)
+
+	rowsAffected, err := queries.FollowUser(request.Context(), database.FollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error following the user", err, http.StatusBadRequest)
+		return
+	}
+
+	// Only backfill on a new follow, following twice shouldn't do any extra work
+	if rowsAffected > 0 {
+		if err = queries.BackfillTimeline(request.Context(), database.BackfillTimelineParams{
+			FollowerID: userID,
+			FolloweeID: followeeID,
+		}); err != nil {
+			respondWithError(response, request, "There was an error adding their chirps to your timeline", err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		respondWithError(response, request, "There was an error following the user", err, http.StatusInternalServerError)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func (config *apiConfig) unfollowHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	followeeID, err := uuid.Parse(request.PathValue("UserID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing the UUID of the user", err, http.StatusBadRequest)
+		return
+	}
+
+	tx, err := config.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(response, request, "There was an error unfollowing the user", err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	queries := config.dbQueries.WithTx(tx)
+
+	rowsAffected, err := queries.UnfollowUser(request.Context(), database.UnfollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error unfollowing the user", err, http.StatusBadRequest)
+		return
+	}
+	if rowsAffected == 0 {
+		respondWithError(response, request, "You aren't following that user", nil, http.StatusNotFound)
+		return
+	}
+
+	if err = queries.RemoveFolloweeFromTimeline(request.Context(), database.RemoveFolloweeFromTimelineParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	}); err != nil {
+		respondWithError(response, request, "There was an error removing their chirps from your timeline", err, http.StatusInternalServerError)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		respondWithError(response, request, "There was an error unfollowing the user", err, http.StatusInternalServerError)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func (config *apiConfig) getFollowersHandler(response http.ResponseWriter, request *http.Request) {
+	userID, limit, offset, ok := parseFollowListRequest(response, request)
+	if !ok {
+		return
+	}
+
+	count, err := config.dbQueries.CountFollowers(request.Context(), userID)
+	if err != nil {
+		respondWithError(response, request, "There was an error counting the followers", err, http.StatusBadRequest)
+		return
+	}
+	rows, err := config.dbQueries.ListFollowers(request.Context(), database.ListFollowersParams{
+		FolloweeID: userID,
+		Limit:      int32(limit + 1),
+		Offset:     int32(offset),
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching the followers", err, http.StatusBadRequest)
+		return
+	}
+
+	followers := FollowList{Count: count, Users: []FollowEntry{}}
+	for _, row := range rows {
+		followers.Users = append(followers.Users, FollowEntry{row.FollowerID, row.CreatedAt})
+	}
+	respondWithFollowList(response, request, followers, limit, offset)
+}
+
+func (config *apiConfig) getFollowingHandler(response http.ResponseWriter, request *http.Request) {
+	userID, limit, offset, ok := parseFollowListRequest(response, request)
+	if !ok {
+		return
+	}
+
+	count, err := config.dbQueries.CountFollowing(request.Context(), userID)
+	if err != nil {
+		respondWithError(response, request, "There was an error counting the followed users", err, http.StatusBadRequest)
+		return
+	}
+	rows, err := config.dbQueries.ListFollowing(request.Context(), database.ListFollowingParams{
+		FollowerID: userID,
+		Limit:      int32(limit + 1),
+		Offset:     int32(offset),
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching the followed users", err, http.StatusBadRequest)
+		return
+	}
+
+	following := FollowList{Count: count, Users: []FollowEntry{}}
+	for _, row := range rows {
+		following.Users = append(following.Users, FollowEntry{row.FolloweeID, row.CreatedAt})
+	}
+	respondWithFollowList(response, request, following, limit, offset)
+}
+
+func parseFollowListRequest(response http.ResponseWriter, request *http.Request) (uuid.UUID, int, int, bool) {
+	userID, err := uuid.Parse(request.PathValue("UserID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing the UUID of the user", err, http.StatusBadRequest)
+		return uuid.Nil, 0, 0, false
+	}
+
+	query := request.URL.Query()
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(response, request, err.Error(), err, http.StatusBadRequest)
+		return uuid.Nil, 0, 0, false
+	}
+	offset, err := pagination.ParseOffset(query.Get("offset"))
+	if err != nil {
+		respondWithError(response, request, err.Error(), err, http.StatusBadRequest)
+		return uuid.Nil, 0, 0, false
+	}
+	return userID, limit, offset, true
+}
+
+// respondWithFollowList expects one more user than the limit so it knows whether to link to the next page
+func respondWithFollowList(response http.ResponseWriter, request *http.Request, list FollowList, limit, offset int) {
+	if len(list.Users) > limit {
+		list.Users = list.Users[:limit]
+		response.Header().Set("Link", pagination.NextLink(request.URL, "offset", strconv.Itoa(offset+limit)))
+	}
+	respondWithJSON(response, request, list, http.StatusOK)
+}
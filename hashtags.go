@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/entities"
+	"github.com/vilebile17/chirpy/internal/pagination"
+)
+
+const (
+	trendingWindow          = 24 * time.Hour
+	trendingHalfLife        = 4 * time.Hour
+	trendingRefreshInterval = time.Minute
+	trendingSize            = 10
+)
+
+type TrendingHashtag struct {
+	Tag   string  `json:"tag"`
+	Uses  int64   `json:"uses"`
+	Score float64 `json:"score"`
+}
+
+// trendingCache holds the latest trending list so that GET /api/trending never has to run the (expensive) ranking query
+type trendingCache struct {
+	mu        sync.RWMutex
+	hashtags  []TrendingHashtag
+	updatedAt time.Time
+}
+
+// tagChirp stores the hashtags in a chirp body, it should be called in the same transaction that created the chirp
+func tagChirp(ctx context.Context, queries *database.Queries, chirp database.Chirp) error {
+	tags := entities.Hashtags(chirp.Body)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	if err := queries.CreateHashtags(ctx, tags); err != nil {
+		return err
+	}
+	return queries.TagChirp(ctx, database.TagChirpParams{
+		Tags:    tags,
+		ChirpID: chirp.ID,
+	})
+}
+
+func (config *apiConfig) getHashtagChirpsHandler(response http.ResponseWriter, request *http.Request) {
+	tag := entities.NormaliseHashtag(request.PathValue("tag"))
+	if tag == "" {
+		respondWithError(response, request, "Something went wrong, required format: /api/hashtags/TAG/chirps", nil, http.StatusBadRequest)
+		return
+	}
+
+	query := request.URL.Query()
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(response, request, err.Error(), err, http.StatusBadRequest)
+		return
+	}
+
+	viewerID := getViewerFromHeader(request.Header, config.secret)
+	params := database.GetChirpsByHashtagParams{
+		Tag:      tag,
+		ViewerID: viewerID,
+		RowLimit: int32(limit + 1),
+	}
+	if before := query.Get("before"); before != "" {
+		cursor, err := pagination.DecodeCursor(before)
+		if err != nil {
+			respondWithError(response, request, "The 'before' cursor is invalid", err, http.StatusBadRequest)
+			return
+		}
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	sqlChirps, err := config.dbQueries.GetChirpsByHashtag(request.Context(), params)
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching the Chirps", err, http.StatusBadRequest)
+		return
+	}
+
+	if len(sqlChirps) > limit {
+		sqlChirps = sqlChirps[:limit]
+		last := sqlChirps[len(sqlChirps)-1]
+		next := pagination.EncodeCursor(pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		response.Header().Set("Link", pagination.NextLink(request.URL, "before", next))
+	}
+
+	chirps := []Chirp{}
+	for _, chirp := range sqlChirps {
+		chirps = append(chirps, chirpFromDatabase(chirp))
+	}
+	if err = config.hydrateChirps(request.Context(), viewerID, chirpPointers(chirps)); err != nil {
+		respondWithError(response, request, "There was an error loading the Chirps", err, http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(response, request, chirps, http.StatusOK)
+}
+
+func (config *apiConfig) getTrendingHandler(response http.ResponseWriter, request *http.Request) {
+	config.trending.mu.RLock()
+	defer config.trending.mu.RUnlock()
+
+	hashtags := config.trending.hashtags
+	if hashtags == nil {
+		hashtags = []TrendingHashtag{}
+	}
+
+	respondWithJSON(response, request, struct {
+		Hashtags  []TrendingHashtag `json:"hashtags"`
+		UpdatedAt time.Time         `json:"updated_at"`
+	}{
+		hashtags,
+		config.trending.updatedAt,
+	}, http.StatusOK)
+}
+
+// refreshTrending reranks the hashtags used in the last trendingWindow. Every use counts for less the older it is,
+// halving every trendingHalfLife, so a tag has to keep being used to stay on the list
+func (config *apiConfig) refreshTrending(ctx context.Context) error {
+	rows, err := config.dbQueries.GetTrendingHashtags(ctx, database.GetTrendingHashtagsParams{
+		HalfLifeSeconds: trendingHalfLife.Seconds(),
+		WindowSeconds:   trendingWindow.Seconds(),
+		RowLimit:        trendingSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	hashtags := []TrendingHashtag{}
+	for _, row := range rows {
+		hashtags = append(hashtags, TrendingHashtag{row.Tag, row.Uses, row.Score})
+	}
+
+	config.trending.mu.Lock()
+	defer config.trending.mu.Unlock()
+	config.trending.hashtags = hashtags
+	config.trending.updatedAt = time.Now().UTC()
+	return nil
+}
+
+func (config *apiConfig) trendingWorker(ctx context.Context) {
+	ticker := time.NewTicker(trendingRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := config.refreshTrending(ctx); err != nil {
+			log.Printf("Error refreshing the trending hashtags: %s\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
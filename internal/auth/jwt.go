@@ -29,23 +29,34 @@ func MakeJWT(userID uuid.UUID, tokenSecret string) (string, error) {
 }
 
 func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+	id, _, err := ValidateJWTWithExpiry(tokenString, tokenSecret)
+	return id, err
+}
+
+// ValidateJWTWithExpiry is ValidateJWT but also says when the token runs out, for connections that outlive it
+func ValidateJWTWithExpiry(tokenString, tokenSecret string) (uuid.UUID, time.Time, error) {
 	claims := jwt.RegisteredClaims{}
 	token, err := jwt.ParseWithClaims(tokenString, &claims,
 		func(t *jwt.Token) (any, error) {
 			return []byte(tokenSecret), nil
 		})
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, time.Time{}, err
 	}
 	if !token.Valid {
-		return uuid.Nil, errors.New("error: token invalid")
+		return uuid.Nil, time.Time{}, errors.New("error: token invalid")
 	}
 
 	id, err := uuid.Parse(claims.Subject)
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, time.Time{}, err
+	}
+	// A token without an expiry never runs out, that is a zero time
+	expiresAt := time.Time{}
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
 	}
-	return id, nil
+	return id, expiresAt, nil
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
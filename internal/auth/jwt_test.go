@@ -142,3 +142,22 @@ func TestGetBearerToken(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateJWTWithExpiry(t *testing.T) {
+	userID := uuid.New()
+	signedString, err := MakeJWT(userID, "secret")
+	if err != nil {
+		t.Fatalf("An error occured unexpectedly during creation of JWT: %s", err)
+	}
+
+	id, expiresAt, err := ValidateJWTWithExpiry(signedString, "secret")
+	if err != nil {
+		t.Fatalf("An error occured unexpectedly during validation of JWT: %s", err)
+	}
+	if id != userID {
+		t.Fatalf("Expected %v but got %v", userID, id)
+	}
+	if untilExpiry := time.Until(expiresAt); untilExpiry <= 0 || untilExpiry > time.Hour {
+		t.Fatalf("The expiry time doesn't look right: %v", expiresAt)
+	}
+}
@@ -0,0 +1,89 @@
+// Package blobstore: somewhere to keep uploaded files. Handlers only see the BlobStore interface so the files can move
+// from the local disk to something like S3 without touching them.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var ErrInvalidKey = errors.New("blobstore: invalid key")
+
+type BlobStore interface {
+	// Put saves the data under key, replacing anything that was already there
+	Put(ctx context.Context, key string, data io.Reader) error
+	// Delete removes the blob, deleting a key that doesn't exist isn't an error
+	Delete(ctx context.Context, key string) error
+	// URL is where clients can download the blob from
+	URL(key string) string
+}
+
+// FileSystem keeps blobs as files under root, e.g. for serving with http.FileServer
+type FileSystem struct {
+	root    string
+	baseURL string
+}
+
+func NewFileSystem(root, baseURL string) (*FileSystem, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileSystem{root: root, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+// path turns a key like "avatars/abc/48.jpg" into a path under root, refusing anything that could escape it
+func (store *FileSystem) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key || strings.HasPrefix(key, "..") {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(store.root, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temporary file first so that nobody can download half a file
+func (store *FileSystem) Put(_ context.Context, key string, data io.Reader) error {
+	filePath, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(filePath), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err = io.Copy(file, data); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(file.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), filePath)
+}
+
+func (store *FileSystem) Delete(_ context.Context, key string) error {
+	filePath, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(filePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (store *FileSystem) URL(key string) string {
+	return store.baseURL + "/" + key
+}
@@ -0,0 +1,48 @@
+package blobstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSystemPutAndDelete(t *testing.T) {
+	root := t.TempDir()
+	store, err := NewFileSystem(root, "/media/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = store.Put(context.Background(), "avatars/someone/48.jpg", strings.NewReader("image")); err != nil {
+		t.Fatalf("Couldn't put the blob: %s", err)
+	}
+	data, err := os.ReadFile(filepath.Join(root, "avatars", "someone", "48.jpg"))
+	if err != nil || string(data) != "image" {
+		t.Fatalf("The blob wasn't saved properly: %q, %v", data, err)
+	}
+	if url := store.URL("avatars/someone/48.jpg"); url != "/media/avatars/someone/48.jpg" {
+		t.Fatalf("Got the wrong URL: %v", url)
+	}
+
+	if err = store.Delete(context.Background(), "avatars/someone/48.jpg"); err != nil {
+		t.Fatalf("Couldn't delete the blob: %s", err)
+	}
+	if err = store.Delete(context.Background(), "avatars/someone/48.jpg"); err != nil {
+		t.Fatalf("Deleting twice should be fine: %s", err)
+	}
+}
+
+func TestFileSystemRejectsBadKeys(t *testing.T) {
+	store, err := NewFileSystem(t.TempDir(), "/media")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"", "/etc/passwd", "../outside", "avatars/../../outside", "avatars//48.jpg"} {
+		if err = store.Put(context.Background(), key, strings.NewReader("")); err != ErrInvalidKey {
+			t.Fatalf("Expected ErrInvalidKey for %q but got %v", key, err)
+		}
+	}
+}
@@ -7,29 +7,121 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const chirpHasDependents = `-- name: ChirpHasDependents :one
+SELECT EXISTS (
+  SELECT 1 FROM chirps
+  WHERE in_reply_to_id = $1::uuid
+    OR quoted_chirp_id = $1::uuid
+)
+`
+
+func (q *Queries) ChirpHasDependents(ctx context.Context, chirpID uuid.UUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, chirpHasDependents, chirpID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const claimExpiredChirp = `-- name: ClaimExpiredChirp :one
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, tombstoned_at, quoted_chirp_id, rechirp_of_id, edited_at, deleted_at, expires_at, content_warning, sensitive, sensitive_forced FROM chirps
+WHERE expires_at <= NOW()
+ORDER BY expires_at
+LIMIT 1
+FOR UPDATE SKIP LOCKED
+`
+
+func (q *Queries) ClaimExpiredChirp(ctx context.Context) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, claimExpiredChirp)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.InReplyToID,
+		&i.TombstonedAt,
+		&i.QuotedChirpID,
+		&i.RechirpOfID,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveForced,
+	)
+	return i, err
+}
+
+const claimPurgeableChirp = `-- name: ClaimPurgeableChirp :one
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, tombstoned_at, quoted_chirp_id, rechirp_of_id, edited_at, deleted_at, expires_at, content_warning, sensitive, sensitive_forced FROM chirps
+WHERE deleted_at < NOW() - make_interval(secs => $1::float8)
+  AND rechirp_of_id IS NULL
+ORDER BY deleted_at
+LIMIT 1
+FOR UPDATE SKIP LOCKED
+`
+
+func (q *Queries) ClaimPurgeableChirp(ctx context.Context, maxAgeSeconds float64) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, claimPurgeableChirp, maxAgeSeconds)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.InReplyToID,
+		&i.TombstonedAt,
+		&i.QuotedChirpID,
+		&i.RechirpOfID,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveForced,
+	)
+	return i, err
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to_id, quoted_chirp_id, expires_at, content_warning, sensitive)
 VALUES (
   gen_random_uuid(),
   NOW(),
   NOW(),
   $1,
-  $2
+  $2,
+  $3,
+  $4,
+  $5,
+  $6,
+  $7
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, tombstoned_at, quoted_chirp_id, rechirp_of_id, edited_at, deleted_at, expires_at, content_warning, sensitive, sensitive_forced
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body           string
+	UserID         uuid.UUID
+	InReplyToID    uuid.NullUUID
+	QuotedChirpID  uuid.NullUUID
+	ExpiresAt      sql.NullTime
+	ContentWarning string
+	Sensitive      bool
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID, arg.InReplyToID, arg.QuotedChirpID, arg.ExpiresAt, arg.ContentWarning, arg.Sensitive)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,6 +129,60 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.InReplyToID,
+		&i.TombstonedAt,
+		&i.QuotedChirpID,
+		&i.RechirpOfID,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveForced,
+	)
+	return i, err
+}
+
+const createRechirp = `-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, rechirp_of_id)
+VALUES (
+  gen_random_uuid(),
+  NOW(),
+  NOW(),
+  '',
+  $1,
+  $2
+)
+ON CONFLICT (user_id, rechirp_of_id) WHERE rechirp_of_id IS NOT NULL DO NOTHING
+RETURNING id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, tombstoned_at, quoted_chirp_id, rechirp_of_id, edited_at, deleted_at, expires_at, content_warning, sensitive, sensitive_forced
+`
+
+type CreateRechirpParams struct {
+	UserID      uuid.UUID
+	RechirpOfID uuid.NullUUID
+}
+
+func (q *Queries) CreateRechirp(ctx context.Context, arg CreateRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createRechirp, arg.UserID, arg.RechirpOfID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.InReplyToID,
+		&i.TombstonedAt,
+		&i.QuotedChirpID,
+		&i.RechirpOfID,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveForced,
 	)
 	return i, err
 }
@@ -51,8 +197,57 @@ func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deleteRechirp = `-- name: DeleteRechirp :one
+DELETE FROM chirps
+WHERE user_id = $1
+  AND rechirp_of_id = $2
+RETURNING id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, tombstoned_at, quoted_chirp_id, rechirp_of_id, edited_at, deleted_at, expires_at, content_warning, sensitive, sensitive_forced
+`
+
+type DeleteRechirpParams struct {
+	UserID      uuid.UUID
+	RechirpOfID uuid.NullUUID
+}
+
+func (q *Queries) DeleteRechirp(ctx context.Context, arg DeleteRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, deleteRechirp, arg.UserID, arg.RechirpOfID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.InReplyToID,
+		&i.TombstonedAt,
+		&i.QuotedChirpID,
+		&i.RechirpOfID,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveForced,
+	)
+	return i, err
+}
+
+const deleteRechirpsOf = `-- name: DeleteRechirpsOf :exec
+DELETE FROM chirps
+WHERE rechirp_of_id = $1
+`
+
+func (q *Queries) DeleteRechirpsOf(ctx context.Context, rechirpOfID uuid.NullUUID) error {
+	_, err := q.db.ExecContext(ctx, deleteRechirpsOf, rechirpOfID)
+	return err
+}
+
 const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, tombstoned_at, quoted_chirp_id, rechirp_of_id, edited_at, deleted_at, expires_at, content_warning, sensitive, sensitive_forced FROM chirps
+WHERE tombstoned_at IS NULL
+  AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW())
 ORDER BY created_at ASC
 `
 
@@ -71,6 +266,264 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.InReplyToID,
+			&i.TombstonedAt,
+			&i.QuotedChirpID,
+			&i.RechirpOfID,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.ExpiresAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.SensitiveForced,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpAncestors = `-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors (id, in_reply_to_id, depth) AS (
+  SELECT chirps.id, chirps.in_reply_to_id, 0
+  FROM chirps
+  WHERE chirps.id = $1
+  UNION ALL
+  SELECT chirps.id, chirps.in_reply_to_id, ancestors.depth + 1
+  FROM chirps
+  JOIN ancestors ON chirps.id = ancestors.in_reply_to_id
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.in_reply_to_id, chirps.tombstoned_at, chirps.quoted_chirp_id, chirps.rechirp_of_id, chirps.edited_at, chirps.deleted_at, chirps.expires_at, chirps.content_warning, chirps.sensitive, chirps.sensitive_forced FROM chirps
+JOIN ancestors ON chirps.id = ancestors.id
+WHERE ancestors.depth > 0
+  AND ($2::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = $2::uuid AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $2::uuid)
+  ))
+ORDER BY ancestors.depth DESC
+`
+
+type GetChirpAncestorsParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirpAncestors(ctx context.Context, arg GetChirpAncestorsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpAncestors, arg.ID, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.InReplyToID,
+			&i.TombstonedAt,
+			&i.QuotedChirpID,
+			&i.RechirpOfID,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.ExpiresAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.SensitiveForced,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpDescendants = `-- name: GetChirpDescendants :many
+WITH RECURSIVE descendants (id, depth, path) AS (
+  SELECT chirps.id, 1, ARRAY[to_char(chirps.created_at, 'YYYYMMDDHH24MISSUS') || chirps.id::text]
+  FROM chirps
+  WHERE chirps.in_reply_to_id = $1
+    AND ($2::uuid IS NULL OR NOT EXISTS (
+      SELECT 1 FROM blocks
+      WHERE (blocks.blocker_id = $2::uuid AND blocks.blocked_id = chirps.user_id)
+        OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $2::uuid)
+    ))
+  UNION ALL
+  SELECT chirps.id, descendants.depth + 1, descendants.path || (to_char(chirps.created_at, 'YYYYMMDDHH24MISSUS') || chirps.id::text)
+  FROM chirps
+  JOIN descendants ON chirps.in_reply_to_id = descendants.id
+  WHERE $2::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = $2::uuid AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $2::uuid)
+  )
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.in_reply_to_id, chirps.tombstoned_at, chirps.quoted_chirp_id, chirps.rechirp_of_id, chirps.edited_at, chirps.deleted_at, chirps.expires_at, chirps.content_warning, chirps.sensitive, chirps.sensitive_forced, descendants.depth FROM chirps
+JOIN descendants ON chirps.id = descendants.id
+ORDER BY descendants.path
+LIMIT $3
+OFFSET $4
+`
+
+type GetChirpDescendantsParams struct {
+	RootID    uuid.NullUUID
+	ViewerID  uuid.NullUUID
+	RowLimit  int32
+	RowOffset int32
+}
+
+type GetChirpDescendantsRow struct {
+	Chirp Chirp
+	Depth int32
+}
+
+func (q *Queries) GetChirpDescendants(ctx context.Context, arg GetChirpDescendantsParams) ([]GetChirpDescendantsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpDescendants, arg.RootID, arg.ViewerID, arg.RowLimit, arg.RowOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpDescendantsRow
+	for rows.Next() {
+		var i GetChirpDescendantsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.SearchVector,
+			&i.Chirp.InReplyToID,
+			&i.Chirp.TombstonedAt,
+			&i.Chirp.QuotedChirpID,
+			&i.Chirp.RechirpOfID,
+			&i.Chirp.EditedAt,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.ExpiresAt,
+			&i.Chirp.ContentWarning,
+			&i.Chirp.Sensitive,
+			&i.Chirp.SensitiveForced,
+			&i.Depth,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpForViewer = `-- name: GetChirpForViewer :one
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, tombstoned_at, quoted_chirp_id, rechirp_of_id, edited_at, deleted_at, expires_at, content_warning, sensitive, sensitive_forced FROM chirps
+WHERE id = $1
+  AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND ($2::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = $2::uuid AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $2::uuid)
+  ))
+`
+
+type GetChirpForViewerParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirpForViewer(ctx context.Context, arg GetChirpForViewerParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpForViewer, arg.ID, arg.ViewerID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.InReplyToID,
+		&i.TombstonedAt,
+		&i.QuotedChirpID,
+		&i.RechirpOfID,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveForced,
+	)
+	return i, err
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, tombstoned_at, quoted_chirp_id, rechirp_of_id, edited_at, deleted_at, expires_at, content_warning, sensitive, sensitive_forced FROM chirps
+WHERE id = ANY($1::uuid[])
+  AND ($2::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = $2::uuid AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $2::uuid)
+  ))
+  AND ($2::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = $2::uuid
+      AND mutes.muted_id = chirps.user_id
+  ))
+`
+
+type GetChirpsByIDsParams struct {
+	Ids      []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, arg GetChirpsByIDsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(arg.Ids), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.InReplyToID,
+			&i.TombstonedAt,
+			&i.QuotedChirpID,
+			&i.RechirpOfID,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.ExpiresAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.SensitiveForced,
 		); err != nil {
 			return nil, err
 		}
@@ -86,8 +539,10 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getOneChirp = `-- name: GetOneChirp :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, tombstoned_at, quoted_chirp_id, rechirp_of_id, edited_at, deleted_at, expires_at, content_warning, sensitive, sensitive_forced FROM chirps
 WHERE id = $1
+  AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW())
 `
 
 func (q *Queries) GetOneChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -99,13 +554,324 @@ func (q *Queries) GetOneChirp(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.InReplyToID,
+		&i.TombstonedAt,
+		&i.QuotedChirpID,
+		&i.RechirpOfID,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveForced,
 	)
 	return i, err
 }
 
+const getTrashedChirp = `-- name: GetTrashedChirp :one
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, tombstoned_at, quoted_chirp_id, rechirp_of_id, edited_at, deleted_at, expires_at, content_warning, sensitive, sensitive_forced FROM chirps
+WHERE id = $1
+  AND user_id = $2
+  AND deleted_at IS NOT NULL
+  AND tombstoned_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND rechirp_of_id IS NULL
+`
+
+type GetTrashedChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetTrashedChirp(ctx context.Context, arg GetTrashedChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getTrashedChirp, arg.ID, arg.UserID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.InReplyToID,
+		&i.TombstonedAt,
+		&i.QuotedChirpID,
+		&i.RechirpOfID,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveForced,
+	)
+	return i, err
+}
+
+const listChirpsAscending = `-- name: ListChirpsAscending :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, tombstoned_at, quoted_chirp_id, rechirp_of_id, edited_at, deleted_at, expires_at, content_warning, sensitive, sensitive_forced FROM chirps
+WHERE tombstoned_at IS NULL
+  AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND ($1::uuid IS NULL OR user_id = $1::uuid)
+  AND ($2::timestamp IS NULL
+    OR (created_at, id) > ($2::timestamp, $3::uuid))
+  AND ($4::timestamp IS NULL
+    OR (created_at, id) < ($4::timestamp, $5::uuid))
+  AND ($6::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = $6::uuid AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $6::uuid)
+  ))
+  AND ($6::uuid IS NULL OR $1::uuid IS NOT NULL OR NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = $6::uuid
+      AND mutes.muted_id = chirps.user_id
+  ))
+  AND ($6::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM chirps AS originals
+    WHERE originals.id = chirps.rechirp_of_id
+      AND (EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = $6::uuid AND blocks.blocked_id = originals.user_id)
+          OR (blocks.blocker_id = originals.user_id AND blocks.blocked_id = $6::uuid)
+      ) OR EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = $6::uuid
+          AND mutes.muted_id = originals.user_id
+      ))
+  ))
+  AND (NOT $7::boolean OR NOT (chirps.sensitive OR EXISTS (
+    SELECT 1 FROM chirps AS originals
+    WHERE originals.id = chirps.rechirp_of_id
+      AND originals.sensitive
+  )))
+ORDER BY created_at ASC, id ASC
+LIMIT $8
+`
+
+type ListChirpsAscendingParams struct {
+	AuthorID        uuid.NullUUID
+	AfterCreatedAt  sql.NullTime
+	AfterID         uuid.NullUUID
+	BeforeCreatedAt sql.NullTime
+	BeforeID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	HideSensitive   bool
+	RowLimit        int32
+}
+
+func (q *Queries) ListChirpsAscending(ctx context.Context, arg ListChirpsAscendingParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsAscending, arg.AuthorID, arg.AfterCreatedAt, arg.AfterID, arg.BeforeCreatedAt, arg.BeforeID, arg.ViewerID, arg.HideSensitive, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.InReplyToID,
+			&i.TombstonedAt,
+			&i.QuotedChirpID,
+			&i.RechirpOfID,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.ExpiresAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.SensitiveForced,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsDescending = `-- name: ListChirpsDescending :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, tombstoned_at, quoted_chirp_id, rechirp_of_id, edited_at, deleted_at, expires_at, content_warning, sensitive, sensitive_forced FROM chirps
+WHERE tombstoned_at IS NULL
+  AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND ($1::uuid IS NULL OR user_id = $1::uuid)
+  AND ($2::timestamp IS NULL
+    OR (created_at, id) > ($2::timestamp, $3::uuid))
+  AND ($4::timestamp IS NULL
+    OR (created_at, id) < ($4::timestamp, $5::uuid))
+  AND ($6::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = $6::uuid AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $6::uuid)
+  ))
+  AND ($6::uuid IS NULL OR $1::uuid IS NOT NULL OR NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = $6::uuid
+      AND mutes.muted_id = chirps.user_id
+  ))
+  AND ($6::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM chirps AS originals
+    WHERE originals.id = chirps.rechirp_of_id
+      AND (EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = $6::uuid AND blocks.blocked_id = originals.user_id)
+          OR (blocks.blocker_id = originals.user_id AND blocks.blocked_id = $6::uuid)
+      ) OR EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = $6::uuid
+          AND mutes.muted_id = originals.user_id
+      ))
+  ))
+  AND (NOT $7::boolean OR NOT (chirps.sensitive OR EXISTS (
+    SELECT 1 FROM chirps AS originals
+    WHERE originals.id = chirps.rechirp_of_id
+      AND originals.sensitive
+  )))
+ORDER BY created_at DESC, id DESC
+LIMIT $8
+`
+
+type ListChirpsDescendingParams struct {
+	AuthorID        uuid.NullUUID
+	AfterCreatedAt  sql.NullTime
+	AfterID         uuid.NullUUID
+	BeforeCreatedAt sql.NullTime
+	BeforeID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	HideSensitive   bool
+	RowLimit        int32
+}
+
+func (q *Queries) ListChirpsDescending(ctx context.Context, arg ListChirpsDescendingParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsDescending, arg.AuthorID, arg.AfterCreatedAt, arg.AfterID, arg.BeforeCreatedAt, arg.BeforeID, arg.ViewerID, arg.HideSensitive, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.InReplyToID,
+			&i.TombstonedAt,
+			&i.QuotedChirpID,
+			&i.RechirpOfID,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.ExpiresAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.SensitiveForced,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrashedChirps = `-- name: ListTrashedChirps :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, tombstoned_at, quoted_chirp_id, rechirp_of_id, edited_at, deleted_at, expires_at, content_warning, sensitive, sensitive_forced FROM chirps
+WHERE user_id = $1
+  AND deleted_at IS NOT NULL
+  AND tombstoned_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND rechirp_of_id IS NULL
+  AND ($2::timestamp IS NULL
+    OR (deleted_at, id) < ($2::timestamp, $3::uuid))
+ORDER BY deleted_at DESC, id DESC
+LIMIT $4
+`
+
+type ListTrashedChirpsParams struct {
+	UserID          uuid.UUID
+	BeforeDeletedAt sql.NullTime
+	BeforeID        uuid.NullUUID
+	RowLimit        int32
+}
+
+func (q *Queries) ListTrashedChirps(ctx context.Context, arg ListTrashedChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listTrashedChirps, arg.UserID, arg.BeforeDeletedAt, arg.BeforeID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.InReplyToID,
+			&i.TombstonedAt,
+			&i.QuotedChirpID,
+			&i.RechirpOfID,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.ExpiresAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.SensitiveForced,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restoreChirp = `-- name: RestoreChirp :exec
+UPDATE chirps
+SET deleted_at = NULL
+WHERE id = $1
+  OR rechirp_of_id = $1
+`
+
+func (q *Queries) RestoreChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, restoreChirp, id)
+	return err
+}
+
 const searchChirpByAuthor = `-- name: SearchChirpByAuthor :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, tombstoned_at, quoted_chirp_id, rechirp_of_id, edited_at, deleted_at, expires_at, content_warning, sensitive, sensitive_forced FROM chirps
 WHERE user_id = $1
+  AND tombstoned_at IS NULL
+  AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW())
 `
 
 func (q *Queries) SearchChirpByAuthor(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
@@ -123,6 +889,107 @@ func (q *Queries) SearchChirpByAuthor(ctx context.Context, userID uuid.UUID) ([]
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.InReplyToID,
+			&i.TombstonedAt,
+			&i.QuotedChirpID,
+			&i.RechirpOfID,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.ExpiresAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.SensitiveForced,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT
+  chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.in_reply_to_id, chirps.tombstoned_at, chirps.quoted_chirp_id, chirps.rechirp_of_id, chirps.edited_at, chirps.deleted_at, chirps.expires_at, chirps.content_warning, chirps.sensitive, chirps.sensitive_forced,
+  ts_rank(chirps.search_vector, to_tsquery('english', $1)) AS rank,
+  ts_headline(
+    'english',
+    replace(replace(replace(replace(replace(chirps.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;'),
+    to_tsquery('english', $1),
+    'StartSel=<mark>, StopSel=</mark>, HighlightAll=TRUE'
+  ) AS snippet
+FROM chirps
+WHERE chirps.search_vector @@ to_tsquery('english', $1)
+  AND chirps.deleted_at IS NULL
+  AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW())
+  AND ($2::uuid IS NULL OR chirps.user_id = $2::uuid)
+  AND ($3::timestamp IS NULL OR chirps.created_at >= $3::timestamp)
+  AND ($4::timestamp IS NULL OR chirps.created_at < $4::timestamp)
+  AND ($5::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = $5::uuid AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $5::uuid)
+  ))
+  AND ($5::uuid IS NULL OR $2::uuid IS NOT NULL OR NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = $5::uuid
+      AND mutes.muted_id = chirps.user_id
+  ))
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT $6
+OFFSET $7
+`
+
+type SearchChirpsParams struct {
+	Query     string
+	AuthorID  uuid.NullUUID
+	Since     sql.NullTime
+	Until     sql.NullTime
+	ViewerID  uuid.NullUUID
+	RowLimit  int32
+	RowOffset int32
+}
+
+type SearchChirpsRow struct {
+	Chirp   Chirp
+	Rank    float32
+	Snippet string
+}
+
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps, arg.Query, arg.AuthorID, arg.Since, arg.Until, arg.ViewerID, arg.RowLimit, arg.RowOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.SearchVector,
+			&i.Chirp.InReplyToID,
+			&i.Chirp.TombstonedAt,
+			&i.Chirp.QuotedChirpID,
+			&i.Chirp.RechirpOfID,
+			&i.Chirp.EditedAt,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.ExpiresAt,
+			&i.Chirp.ContentWarning,
+			&i.Chirp.Sensitive,
+			&i.Chirp.SensitiveForced,
+			&i.Rank,
+			&i.Snippet,
 		); err != nil {
 			return nil, err
 		}
@@ -136,3 +1003,71 @@ func (q *Queries) SearchChirpByAuthor(ctx context.Context, userID uuid.UUID) ([]
 	}
 	return items, nil
 }
+
+const setChirpSensitive = `-- name: SetChirpSensitive :one
+UPDATE chirps
+SET
+  sensitive = $2,
+  sensitive_forced = $3,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, search_vector, in_reply_to_id, tombstoned_at, quoted_chirp_id, rechirp_of_id, edited_at, deleted_at, expires_at, content_warning, sensitive, sensitive_forced
+`
+
+type SetChirpSensitiveParams struct {
+	ID              uuid.UUID
+	Sensitive       bool
+	SensitiveForced bool
+}
+
+func (q *Queries) SetChirpSensitive(ctx context.Context, arg SetChirpSensitiveParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, setChirpSensitive, arg.ID, arg.Sensitive, arg.SensitiveForced)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.InReplyToID,
+		&i.TombstonedAt,
+		&i.QuotedChirpID,
+		&i.RechirpOfID,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.ExpiresAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveForced,
+	)
+	return i, err
+}
+
+const softDeleteChirp = `-- name: SoftDeleteChirp :exec
+UPDATE chirps
+SET deleted_at = NOW()
+WHERE (id = $1 OR rechirp_of_id = $1)
+  AND deleted_at IS NULL
+`
+
+func (q *Queries) SoftDeleteChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, softDeleteChirp, id)
+	return err
+}
+
+const tombstoneChirp = `-- name: TombstoneChirp :exec
+UPDATE chirps
+SET
+  body = '',
+  tombstoned_at = NOW(),
+  deleted_at = NULL,
+  expires_at = NULL,
+  updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) TombstoneChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, tombstoneChirp, id)
+	return err
+}
@@ -6,17 +6,201 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Body            string
+	UserID          uuid.UUID
+	SearchVector    interface{}
+	InReplyToID     uuid.NullUUID
+	TombstonedAt    sql.NullTime
+	QuotedChirpID   uuid.NullUUID
+	RechirpOfID     uuid.NullUUID
+	EditedAt        sql.NullTime
+	DeletedAt       sql.NullTime
+	ExpiresAt       sql.NullTime
+	ContentWarning  string
+	Sensitive       bool
+	SensitiveForced bool
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type ChirpMention struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	Handle    string
+	CreatedAt time.Time
+}
+
+type ChirpRevision struct {
+	ID         uuid.UUID
+	ChirpID    uuid.UUID
+	Body       string
+	CreatedAt  time.Time
+	ReplacedAt time.Time
+}
+
+type Collection struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type CollectionChirp struct {
+	CollectionID uuid.UUID
+	ChirpID      uuid.UUID
+	CreatedAt    time.Time
+}
+
+type Conversation struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Body      string
+	DirectKey sql.NullString
+}
+
+type ConversationParticipant struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	JoinedAt       time.Time
+	LastReadAt     sql.NullTime
+	ClearedAt      sql.NullTime
+}
+
+type Draft struct {
+	ID            uuid.UUID
+	UserID        uuid.UUID
+	Body          string
+	InReplyToID   uuid.NullUUID
+	QuotedChirpID uuid.NullUUID
+	MediaIds      []uuid.UUID
+	PublishAt     sql.NullTime
+	LastError     string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Hashtag struct {
+	Tag       string
+	CreatedAt time.Time
+}
+
+type Like struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Media struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	ChirpID     uuid.NullUUID
+	Position    int32
+	ContentType string
+	Width       int32
+	Height      int32
+	AltText     string
+	Blurhash    string
+	CreatedAt   time.Time
+}
+
+type Message struct {
+	ID             uuid.UUID
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+	CreatedAt      time.Time
+}
+
+type MessageDeletion struct {
+	MessageID uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type ModerationFlag struct {
+	ChirpID   uuid.UUID
+	Words     []string
+	CreatedAt time.Time
+}
+
+type ModerationWord struct {
+	Word      string
+	Action    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Type      string
+	Data      json.RawMessage
+	CreatedAt time.Time
+	ReadAt    sql.NullTime
+}
+
+type PinnedChirp struct {
 	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Poll struct {
+	ChirpID   uuid.UUID
+	ClosesAt  time.Time
+	CreatedAt time.Time
+}
+
+type PollOption struct {
+	ChirpID  uuid.UUID
+	Position int32
+	Text     string
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	Position  int32
+	CreatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -28,11 +212,38 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
+type StreamEvent struct {
+	ID                int64
+	Type              string
+	AuthorID          uuid.UUID
+	Hashtags          []string
+	Data              json.RawMessage
+	CreatedAt         time.Time
+	EmbeddedAuthorIDs []uuid.UUID
+}
+
+type TimelineEntry struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type User struct {
-	ID             uuid.UUID
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Email          string
-	HashedPassword string
-	IsChirpyRed    bool
+	ID                    uuid.UUID
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	Email                 string
+	HashedPassword        string
+	IsChirpyRed           bool
+	Handle                sql.NullString
+	AllowDmsFromStrangers bool
+	DisplayName           string
+	Bio                   string
+	Location              string
+	Website               string
+	HandleChangedAt       sql.NullTime
+	AvatarID              uuid.NullUUID
+	BannerID              uuid.NullUUID
+	IsModerator           bool
+	ExpandSensitive       bool
 }
@@ -7,29 +7,33 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES (
   gen_random_uuid(),
   NOW(),
   NOW(),
   $1,
-  $2
+  $2,
+  $3
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, allow_dms_from_strangers, display_name, bio, location, website, handle_changed_at, avatar_id, banner_id, is_moderator, expand_sensitive
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	Handle         sql.NullString
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword, arg.Handle)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -38,6 +42,143 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.AllowDmsFromStrangers,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.HandleChangedAt,
+		&i.AvatarID,
+		&i.BannerID,
+		&i.IsModerator,
+		&i.ExpandSensitive,
+	)
+	return i, err
+}
+
+const getChirpAuthors = `-- name: GetChirpAuthors :many
+SELECT id, handle, display_name, is_chirpy_red, avatar_id FROM users
+WHERE id = ANY($1::uuid[])
+`
+
+type GetChirpAuthorsRow struct {
+	ID          uuid.UUID
+	Handle      sql.NullString
+	DisplayName string
+	IsChirpyRed bool
+	AvatarID    uuid.NullUUID
+}
+
+func (q *Queries) GetChirpAuthors(ctx context.Context, ids []uuid.UUID) ([]GetChirpAuthorsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpAuthors, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpAuthorsRow
+	for rows.Next() {
+		var i GetChirpAuthorsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Handle,
+			&i.DisplayName,
+			&i.IsChirpyRed,
+			&i.AvatarID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProfileCounts = `-- name: GetProfileCounts :one
+SELECT
+  (SELECT COUNT(*) FROM chirps WHERE chirps.user_id = $1 AND chirps.tombstoned_at IS NULL AND chirps.deleted_at IS NULL AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW())) AS chirp_count,
+  (SELECT COUNT(*) FROM follows WHERE follows.followee_id = $1) AS follower_count,
+  (SELECT COUNT(*) FROM follows WHERE follows.follower_id = $1) AS following_count
+`
+
+type GetProfileCountsRow struct {
+	ChirpCount     int64
+	FollowerCount  int64
+	FollowingCount int64
+}
+
+func (q *Queries) GetProfileCounts(ctx context.Context, userID uuid.UUID) (GetProfileCountsRow, error) {
+	row := q.db.QueryRowContext(ctx, getProfileCounts, userID)
+	var i GetProfileCountsRow
+	err := row.Scan(
+		&i.ChirpCount,
+		&i.FollowerCount,
+		&i.FollowingCount,
+	)
+	return i, err
+}
+
+const getUserByHandle = `-- name: GetUserByHandle :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, allow_dms_from_strangers, display_name, bio, location, website, handle_changed_at, avatar_id, banner_id, is_moderator, expand_sensitive FROM users
+WHERE LOWER(handle) = LOWER($1)
+`
+
+func (q *Queries) GetUserByHandle(ctx context.Context, handle string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByHandle, handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.AllowDmsFromStrangers,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.HandleChangedAt,
+		&i.AvatarID,
+		&i.BannerID,
+		&i.IsModerator,
+		&i.ExpandSensitive,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, allow_dms_from_strangers, display_name, bio, location, website, handle_changed_at, avatar_id, banner_id, is_moderator, expand_sensitive FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.AllowDmsFromStrangers,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.HandleChangedAt,
+		&i.AvatarID,
+		&i.BannerID,
+		&i.IsModerator,
+		&i.ExpandSensitive,
 	)
 	return i, err
 }
@@ -52,7 +193,7 @@ func (q *Queries) ResetUsers(ctx context.Context) error {
 }
 
 const searchUsersByEmail = `-- name: SearchUsersByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, allow_dms_from_strangers, display_name, bio, location, website, handle_changed_at, avatar_id, banner_id, is_moderator, expand_sensitive FROM users
 WHERE email = $1
 `
 
@@ -66,6 +207,135 @@ func (q *Queries) SearchUsersByEmail(ctx context.Context, email string) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.AllowDmsFromStrangers,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.HandleChangedAt,
+		&i.AvatarID,
+		&i.BannerID,
+		&i.IsModerator,
+		&i.ExpandSensitive,
+	)
+	return i, err
+}
+
+const setUserAvatar = `-- name: SetUserAvatar :one
+UPDATE users
+SET
+  avatar_id = $2,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, allow_dms_from_strangers, display_name, bio, location, website, handle_changed_at, avatar_id, banner_id, is_moderator, expand_sensitive
+`
+
+type SetUserAvatarParams struct {
+	ID       uuid.UUID
+	AvatarID uuid.NullUUID
+}
+
+func (q *Queries) SetUserAvatar(ctx context.Context, arg SetUserAvatarParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserAvatar, arg.ID, arg.AvatarID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.AllowDmsFromStrangers,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.HandleChangedAt,
+		&i.AvatarID,
+		&i.BannerID,
+		&i.IsModerator,
+		&i.ExpandSensitive,
+	)
+	return i, err
+}
+
+const setUserBanner = `-- name: SetUserBanner :one
+UPDATE users
+SET
+  banner_id = $2,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, allow_dms_from_strangers, display_name, bio, location, website, handle_changed_at, avatar_id, banner_id, is_moderator, expand_sensitive
+`
+
+type SetUserBannerParams struct {
+	ID       uuid.UUID
+	BannerID uuid.NullUUID
+}
+
+func (q *Queries) SetUserBanner(ctx context.Context, arg SetUserBannerParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserBanner, arg.ID, arg.BannerID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.AllowDmsFromStrangers,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.HandleChangedAt,
+		&i.AvatarID,
+		&i.BannerID,
+		&i.IsModerator,
+		&i.ExpandSensitive,
+	)
+	return i, err
+}
+
+const setUserHandle = `-- name: SetUserHandle :one
+UPDATE users
+SET
+  handle = $2,
+  handle_changed_at = CASE WHEN handle IS NULL OR LOWER(handle) = LOWER($2) THEN handle_changed_at ELSE NOW() END,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, allow_dms_from_strangers, display_name, bio, location, website, handle_changed_at, avatar_id, banner_id, is_moderator, expand_sensitive
+`
+
+type SetUserHandleParams struct {
+	ID     uuid.UUID
+	Handle sql.NullString
+}
+
+func (q *Queries) SetUserHandle(ctx context.Context, arg SetUserHandleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserHandle, arg.ID, arg.Handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.AllowDmsFromStrangers,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.HandleChangedAt,
+		&i.AvatarID,
+		&i.BannerID,
+		&i.IsModerator,
+		&i.ExpandSensitive,
 	)
 	return i, err
 }
@@ -77,7 +347,7 @@ SET
   hashed_password = $3,
   updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, allow_dms_from_strangers, display_name, bio, location, website, handle_changed_at, avatar_id, banner_id, is_moderator, expand_sensitive
 `
 
 type UpdateUserEmailAndPasswordParams struct {
@@ -96,6 +366,103 @@ func (q *Queries) UpdateUserEmailAndPassword(ctx context.Context, arg UpdateUser
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.AllowDmsFromStrangers,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.HandleChangedAt,
+		&i.AvatarID,
+		&i.BannerID,
+		&i.IsModerator,
+		&i.ExpandSensitive,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET
+  display_name = $2,
+  bio = $3,
+  location = $4,
+  website = $5,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, allow_dms_from_strangers, display_name, bio, location, website, handle_changed_at, avatar_id, banner_id, is_moderator, expand_sensitive
+`
+
+type UpdateUserProfileParams struct {
+	ID          uuid.UUID
+	DisplayName string
+	Bio         string
+	Location    string
+	Website     string
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile, arg.ID, arg.DisplayName, arg.Bio, arg.Location, arg.Website)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.AllowDmsFromStrangers,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.HandleChangedAt,
+		&i.AvatarID,
+		&i.BannerID,
+		&i.IsModerator,
+		&i.ExpandSensitive,
+	)
+	return i, err
+}
+
+const updateUserSettings = `-- name: UpdateUserSettings :one
+UPDATE users
+SET
+  allow_dms_from_strangers = $2,
+  expand_sensitive = $3,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, allow_dms_from_strangers, display_name, bio, location, website, handle_changed_at, avatar_id, banner_id, is_moderator, expand_sensitive
+`
+
+type UpdateUserSettingsParams struct {
+	ID                    uuid.UUID
+	AllowDmsFromStrangers bool
+	ExpandSensitive       bool
+}
+
+func (q *Queries) UpdateUserSettings(ctx context.Context, arg UpdateUserSettingsParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserSettings, arg.ID, arg.AllowDmsFromStrangers, arg.ExpandSensitive)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.AllowDmsFromStrangers,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.HandleChangedAt,
+		&i.AvatarID,
+		&i.BannerID,
+		&i.IsModerator,
+		&i.ExpandSensitive,
 	)
 	return i, err
 }
@@ -105,7 +472,7 @@ UPDATE users
 SET
   is_chirpy_red = TRUE
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, allow_dms_from_strangers, display_name, bio, location, website, handle_changed_at, avatar_id, banner_id, is_moderator, expand_sensitive
 `
 
 func (q *Queries) UpgradeToChirpyRed(ctx context.Context, id uuid.UUID) (User, error) {
@@ -118,6 +485,17 @@ func (q *Queries) UpgradeToChirpyRed(ctx context.Context, id uuid.UUID) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.AllowDmsFromStrangers,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.HandleChangedAt,
+		&i.AvatarID,
+		&i.BannerID,
+		&i.IsModerator,
+		&i.ExpandSensitive,
 	)
 	return i, err
 }
@@ -0,0 +1,128 @@
+// Package entities: finds the parts of a chirp body that clients should render as links, like #hashtags and @mentions.
+package entities
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	TypeHashtag = "hashtag"
+	TypeMention = "mention"
+
+	maxHashtagLength = 100
+	MaxHandleLength  = 15
+)
+
+// Entity is a piece of a chirp body. Start and End are offsets in unicode code points (not bytes), End is exclusive.
+// Value is the normalised form, e.g. the hashtag without its # and in lower case, or the handle without its @ and in lower case
+type Entity struct {
+	Type  string
+	Start int
+	End   int
+	Value string
+}
+
+// Parse returns every entity in the body in the order they appear
+func Parse(body string) []Entity {
+	runes := []rune(body)
+	found := []Entity{}
+
+	for i := 0; i < len(runes); i++ {
+		if (runes[i] != '#' && runes[i] != '@') || (i > 0 && isWordRune(runes[i-1])) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isWordRune(runes[end]) {
+			end++
+		}
+
+		word := string(runes[i+1 : end])
+		switch {
+		case runes[i] == '#' && isHashtag(word):
+			found = append(found, Entity{Type: TypeHashtag, Start: i, End: end, Value: NormaliseHashtag(word)})
+		case runes[i] == '@' && ValidHandle(word):
+			found = append(found, Entity{Type: TypeMention, Start: i, End: end, Value: NormaliseHandle(word)})
+		}
+		i = end - 1
+	}
+	return found
+}
+
+// Hashtags gives the distinct, normalised hashtags in the body
+func Hashtags(body string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, entity := range Parse(body) {
+		if entity.Type != TypeHashtag || seen[entity.Value] {
+			continue
+		}
+		seen[entity.Value] = true
+		tags = append(tags, entity.Value)
+	}
+	return tags
+}
+
+// Mentions gives the distinct, normalised handles mentioned in the body
+func Mentions(body string) []string {
+	handles := []string{}
+	seen := map[string]bool{}
+	for _, entity := range Parse(body) {
+		if entity.Type != TypeMention || seen[entity.Value] {
+			continue
+		}
+		seen[entity.Value] = true
+		handles = append(handles, entity.Value)
+	}
+	return handles
+}
+
+// ValidHandle checks that a handle is 1 to MaxHandleLength ascii letters, digits or underscores
+func ValidHandle(handle string) bool {
+	if handle == "" || len(handle) > MaxHandleLength {
+		return false
+	}
+	for _, r := range handle {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// reservedHandles can't be taken by anyone, either because they'd clash with a route (like /api/users/me) or because
+// they'd let someone pass themselves off as Chirpy
+var reservedHandles = map[string]bool{
+	"about": true, "admin": true, "administrator": true, "api": true, "chirpy": true, "everyone": true, "help": true,
+	"here": true, "home": true, "login": true, "logout": true, "me": true, "mod": true, "moderator": true, "null": true,
+	"official": true, "root": true, "search": true, "settings": true, "signup": true, "staff": true, "support": true,
+	"system": true, "trending": true, "undefined": true,
+}
+
+// ReservedHandle reports whether a handle is kept back from users, whatever its case
+func ReservedHandle(handle string) bool {
+	return reservedHandles[NormaliseHandle(handle)]
+}
+
+// NormaliseHandle lower cases a handle and removes the leading @, handles are case insensitive
+func NormaliseHandle(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(handle, "@"))
+}
+
+// NormaliseHashtag lower cases a tag and removes the leading # so that #Go, #go and go are all the same tag
+func NormaliseHashtag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// isHashtag stops things like "#1" or a lone "#" from becoming tags
+func isHashtag(word string) bool {
+	if word == "" || len([]rune(word)) > maxHashtagLength {
+		return false
+	}
+	return strings.ContainsFunc(word, unicode.IsLetter)
+}
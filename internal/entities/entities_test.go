@@ -0,0 +1,85 @@
+package entities
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseHashtags(t *testing.T) {
+	inputs := []string{
+		"no tags here",
+		"#Chirpy is cool",
+		"I love #go, #GoLang and #go!",
+		"email@example.com#notatag and #1 and # alone",
+		"héllo #café",
+	}
+	outputs := [][]Entity{
+		{},
+		{{TypeHashtag, 0, 7, "chirpy"}},
+		{{TypeHashtag, 7, 10, "go"}, {TypeHashtag, 12, 19, "golang"}, {TypeHashtag, 24, 27, "go"}},
+		{},
+		{{TypeHashtag, 6, 11, "café"}},
+	}
+
+	for i := range inputs {
+		found := Parse(inputs[i])
+		if !slices.Equal(found, outputs[i]) {
+			t.Fatalf("Entities don't match for '%v': %v != %v", inputs[i], found, outputs[i])
+		}
+	}
+}
+
+func TestHashtagsAreDistinct(t *testing.T) {
+	tags := Hashtags("#Go #go #GO #chirpy")
+	expected := []string{"go", "chirpy"}
+	if !slices.Equal(tags, expected) {
+		t.Fatalf("Tags don't match: %v != %v", tags, expected)
+	}
+}
+
+func TestParseMentions(t *testing.T) {
+	inputs := []string{
+		"hey @Bob and @alice_99!",
+		"email me at bob@example.com",
+		"@waytoolongtobeahandle is plain text",
+		"@bob @BOB #tag",
+	}
+	outputs := [][]Entity{
+		{{TypeMention, 4, 8, "bob"}, {TypeMention, 13, 22, "alice_99"}},
+		{},
+		{},
+		{{TypeMention, 0, 4, "bob"}, {TypeMention, 5, 9, "bob"}, {TypeHashtag, 10, 14, "tag"}},
+	}
+
+	for i := range inputs {
+		found := Parse(inputs[i])
+		if !slices.Equal(found, outputs[i]) {
+			t.Fatalf("Entities don't match for '%v': %v != %v", inputs[i], found, outputs[i])
+		}
+	}
+
+	if handles := Mentions("@bob @BOB @carol"); !slices.Equal(handles, []string{"bob", "carol"}) {
+		t.Fatalf("Handles don't match: %v", handles)
+	}
+}
+
+func TestValidHandle(t *testing.T) {
+	inputs := []string{"bob", "Bob_99", "", "has space", "héllo", "abcdefghijklmnop"}
+	outputs := []bool{true, true, false, false, false, false}
+
+	for i := range inputs {
+		if ValidHandle(inputs[i]) != outputs[i] {
+			t.Fatalf("Expected ValidHandle('%v') to be %v", inputs[i], outputs[i])
+		}
+	}
+}
+
+func TestReservedHandle(t *testing.T) {
+	inputs := []string{"me", "Admin", "@Chirpy", "chirpy_fan", "vilebile17"}
+	outputs := []bool{true, true, true, false, false}
+	for i := range inputs {
+		if ReservedHandle(inputs[i]) != outputs[i] {
+			t.Fatalf("Expected ReservedHandle('%v') to be %v", inputs[i], outputs[i])
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package images
+
+import (
+	"image"
+	"math"
+	"strings"
+)
+
+const base83Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// BlurHash encodes a tiny blurry preview of the image (https://blurha.sh) with 4x3 components. Clients show it while
+// the real image loads. The image is shrunk first because the hash only needs the rough colours
+func BlurHash(img image.Image) string {
+	const componentsX, componentsY = 4, 3
+
+	bounds := img.Bounds()
+	width, height := 32, 32
+	if bounds.Dx() > bounds.Dy() {
+		height = max(1, 32*bounds.Dy()/bounds.Dx())
+	} else {
+		width = max(1, 32*bounds.Dx()/bounds.Dy())
+	}
+	small := Resize(img, width, height)
+
+	linear := make([][3]float64, width*height)
+	for i := range linear {
+		for c := range 3 {
+			linear[i][c] = sRGBToLinear(small.Pix[i*4+c])
+		}
+	}
+
+	factors := make([][3]float64, 0, componentsX*componentsY)
+	for j := range componentsY {
+		for i := range componentsX {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+
+			var factor [3]float64
+			for y := range height {
+				for x := range width {
+					basis := normalisation * math.Cos(math.Pi*float64(i)*float64(x)/float64(width)) *
+						math.Cos(math.Pi*float64(j)*float64(y)/float64(height))
+					for c := range 3 {
+						factor[c] += basis * linear[y*width+x][c]
+					}
+				}
+			}
+			for c := range 3 {
+				factor[c] /= float64(width * height)
+			}
+			factors = append(factors, factor)
+		}
+	}
+
+	hash := strings.Builder{}
+	hash.WriteString(encodeBase83((componentsX-1)+(componentsY-1)*9, 1))
+
+	dc, ac := factors[0], factors[1:]
+	maximumValue := 1.0
+	if len(ac) > 0 {
+		actualMaximum := 0.0
+		for _, factor := range ac {
+			for c := range 3 {
+				actualMaximum = max(actualMaximum, math.Abs(factor[c]))
+			}
+		}
+		quantisedMaximum := int(max(0, min(82, math.Floor(actualMaximum*166-0.5))))
+		maximumValue = float64(quantisedMaximum+1) / 166
+		hash.WriteString(encodeBase83(quantisedMaximum, 1))
+	} else {
+		hash.WriteString(encodeBase83(0, 1))
+	}
+
+	hash.WriteString(encodeBase83(linearToSRGB(dc[0])<<16+linearToSRGB(dc[1])<<8+linearToSRGB(dc[2]), 4))
+	for _, factor := range ac {
+		quantised := 0
+		for c, multiplier := range []int{19 * 19, 19, 1} {
+			value := math.Floor(signPow(factor[c]/maximumValue, 0.5)*9 + 9.5)
+			quantised += int(max(0, min(18, value))) * multiplier
+		}
+		hash.WriteString(encodeBase83(quantised, 2))
+	}
+	return hash.String()
+}
+
+func encodeBase83(value, length int) string {
+	result := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		result[i] = base83Alphabet[value%83]
+		value /= 83
+	}
+	return string(result)
+}
+
+func sRGBToLinear(value uint8) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(value float64) int {
+	v := max(0, min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value, exponent float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exponent), value)
+}
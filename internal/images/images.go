@@ -0,0 +1,147 @@
+// Package images: decodes uploaded images and turns them into clean, resized JPEGs using only the standard library.
+package images
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+)
+
+const (
+	// MaxDimension stops someone uploading a small file that decodes into an enormous image
+	MaxDimension = 8000
+	jpegQuality  = 85
+)
+
+var (
+	ErrUnsupportedType = errors.New("only jpeg, png and gif images are supported")
+	ErrTooLarge        = errors.New("the image is too large")
+)
+
+var supportedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// Decode checks what the data really is (whatever the upload claimed) and decodes it. The format is "jpeg", "png" or
+// "gif", GIFs only keep their first frame
+func Decode(data []byte) (image.Image, string, error) {
+	if !supportedTypes[http.DetectContentType(data)] {
+		return nil, "", ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if config.Width > MaxDimension || config.Height > MaxDimension {
+		return nil, "", ErrTooLarge
+	}
+
+	return image.Decode(bytes.NewReader(data))
+}
+
+// CropToAspect cuts the middle out of the image so that it is width:height. Very small images keep at least a pixel
+// each way, even if that's not quite the right shape
+func CropToAspect(img image.Image, width, height int) image.Image {
+	bounds := img.Bounds()
+	cropWidth, cropHeight := bounds.Dx(), bounds.Dy()
+	if cropWidth*height > cropHeight*width {
+		cropWidth = max(1, cropHeight*width/height)
+	} else {
+		cropHeight = max(1, cropWidth*height/width)
+	}
+
+	x := bounds.Min.X + (bounds.Dx()-cropWidth)/2
+	y := bounds.Min.Y + (bounds.Dy()-cropHeight)/2
+	return subImage(img, image.Rect(x, y, x+cropWidth, y+cropHeight))
+}
+
+func subImage(img image.Image, rect image.Rectangle) image.Image {
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(rect)
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, rect.Min, draw.Src)
+	return rgba
+}
+
+// Flatten copies the image onto a white background, since JPEGs have no alpha. Resize does this itself, so only call
+// it when resizing the same image more than once to save copying it every time
+func Flatten(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), &image.Uniform{color.White}, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, bounds.Min, draw.Over)
+	return flat
+}
+
+// Resize scales the image to exactly width x height. Each new pixel is the average of the pixels it covers, which looks
+// fine for the downscaling we do. Transparent parts end up white, opaque RGBA images (like the ones from Flatten) are
+// used as they are rather than copied
+func Resize(img image.Image, width, height int) *image.RGBA {
+	src, ok := img.(*image.RGBA)
+	if !ok || !src.Opaque() {
+		src = Flatten(img)
+	}
+
+	srcMin := src.Bounds().Min
+	srcWidth, srcHeight := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		y0 := y * srcHeight / height
+		y1 := max((y+1)*srcHeight/height, y0+1)
+		for x := range width {
+			x0 := x * srcWidth / width
+			x1 := max((x+1)*srcWidth/width, x0+1)
+
+			var r, g, b, a, count int
+			for sy := y0; sy < y1; sy++ {
+				offset := src.PixOffset(srcMin.X+x0, srcMin.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					r += int(src.Pix[offset])
+					g += int(src.Pix[offset+1])
+					b += int(src.Pix[offset+2])
+					a += int(src.Pix[offset+3])
+					offset += 4
+					count++
+				}
+			}
+
+			offset := dst.PixOffset(x, y)
+			dst.Pix[offset] = uint8(r / count)
+			dst.Pix[offset+1] = uint8(g / count)
+			dst.Pix[offset+2] = uint8(b / count)
+			dst.Pix[offset+3] = uint8(a / count)
+		}
+	}
+	return dst
+}
+
+// FitWithin shrinks the image so that neither side is longer than maxSide, keeping its shape. Smaller images are left alone
+func FitWithin(img image.Image, maxSide int) image.Image {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if width <= maxSide && height <= maxSide {
+		return img
+	}
+	if width > height {
+		return Resize(img, maxSide, max(1, height*maxSide/width))
+	}
+	return Resize(img, max(1, width*maxSide/height), maxSide)
+}
+
+// EncodeJPEG writes a brand new JPEG. Nothing from the original file (like EXIF location data) comes along with it
+func EncodeJPEG(writer io.Writer, img image.Image) error {
+	return jpeg.Encode(writer, img, &jpeg.Options{Quality: jpegQuality})
+}
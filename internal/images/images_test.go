@@ -0,0 +1,144 @@
+package images
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 100, 255})
+		}
+	}
+	return img
+}
+
+func TestCropAndResize(t *testing.T) {
+	cropped := CropToAspect(testImage(300, 200), 1, 1)
+	if cropped.Bounds().Dx() != 200 || cropped.Bounds().Dy() != 200 {
+		t.Fatalf("Expected a 200x200 crop but got %v", cropped.Bounds())
+	}
+	// The crop should come from the middle
+	if cropped.Bounds().Min.X != 50 {
+		t.Fatalf("Expected the crop to start at x=50 but got %v", cropped.Bounds())
+	}
+
+	resized := Resize(cropped, 48, 48)
+	if resized.Bounds().Dx() != 48 || resized.Bounds().Dy() != 48 {
+		t.Fatalf("Expected 48x48 but got %v", resized.Bounds())
+	}
+	if r, _, b, _ := resized.At(0, 0).RGBA(); r == 0 || b>>8 != 100 {
+		t.Fatalf("The resized pixels look wrong: %v", resized.At(0, 0))
+	}
+}
+
+func TestCropTinyImage(t *testing.T) {
+	// A 2x2 image is too short for a 3:1 crop, it should still come out at least a pixel tall
+	cropped := CropToAspect(testImage(2, 2), 3, 1)
+	if cropped.Bounds().Dx() != 2 || cropped.Bounds().Dy() != 1 {
+		t.Fatalf("Expected a 2x1 crop but got %v", cropped.Bounds())
+	}
+	if bounds := Resize(cropped, 600, 200).Bounds(); bounds.Dx() != 600 || bounds.Dy() != 200 {
+		t.Fatalf("Expected 600x200 but got %v", bounds)
+	}
+	if bounds := Resize(CropToAspect(testImage(1, 1), 1, 1), 48, 48).Bounds(); bounds.Dx() != 48 || bounds.Dy() != 48 {
+		t.Fatalf("Expected 48x48 but got %v", bounds)
+	}
+}
+
+func TestResizeFlattened(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 40))
+	img.Set(30, 30, color.NRGBA{255, 0, 0, 255})
+	cropped := CropToAspect(img, 1, 1)
+
+	// Transparent pixels go white whether or not the image was flattened first
+	flat := Flatten(cropped)
+	for _, resized := range []*image.RGBA{Resize(cropped, 10, 10), Resize(flat, 10, 10)} {
+		if got := resized.RGBAAt(0, 0); got != (color.RGBA{255, 255, 255, 255}) {
+			t.Fatalf("Expected a white corner but got %v", got)
+		}
+		if got := resized.RGBAAt(7, 7); got.G != 239 {
+			t.Fatalf("Expected one red pixel out of 16 in the resize but got %v", got)
+		}
+	}
+
+	// A crop out of an RGBA image doesn't start at 0,0
+	sub := testImage(300, 200).(*image.RGBA).SubImage(image.Rect(100, 50, 200, 150))
+	if got, want := Resize(sub, 1, 1).RGBAAt(0, 0), Resize(Flatten(sub), 1, 1).RGBAAt(0, 0); got != want {
+		t.Fatalf("Resizing a crop gave %v but resizing its flattened copy gave %v", got, want)
+	}
+}
+
+func TestFitWithin(t *testing.T) {
+	if bounds := FitWithin(testImage(400, 100), 200).Bounds(); bounds.Dx() != 200 || bounds.Dy() != 50 {
+		t.Fatalf("Expected 200x50 but got %v", bounds)
+	}
+	if bounds := FitWithin(testImage(40, 10), 200).Bounds(); bounds.Dx() != 40 || bounds.Dy() != 10 {
+		t.Fatalf("A small image shouldn't change size but got %v", bounds)
+	}
+}
+
+func TestDecodeRejectsOtherTypes(t *testing.T) {
+	if _, _, err := Decode([]byte("<html><body>not an image</body></html>")); err != ErrUnsupportedType {
+		t.Fatalf("Expected ErrUnsupportedType but got %v", err)
+	}
+}
+
+func TestEncodeStripsEXIF(t *testing.T) {
+	buffer := bytes.Buffer{}
+	if err := jpeg.Encode(&buffer, testImage(20, 20), nil); err != nil {
+		t.Fatal(err)
+	}
+	// Put an APP1 EXIF segment straight after the start of image marker
+	exif := append([]byte{0xff, 0xe1, 0x00, 0x0e}, []byte("Exif\x00\x00GPSDATA")...)
+	withEXIF := append(append([]byte{}, buffer.Bytes()[:2]...), append(exif, buffer.Bytes()[2:]...)...)
+
+	img, _, err := Decode(withEXIF)
+	if err != nil {
+		t.Fatalf("Couldn't decode the image: %s", err)
+	}
+	output := bytes.Buffer{}
+	if err = EncodeJPEG(&output, img); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(output.Bytes(), []byte("Exif")) || bytes.Contains(output.Bytes(), []byte("GPSDATA")) {
+		t.Fatal("The EXIF data made it into the new image")
+	}
+}
+
+func TestDecodeRejectsHugeImages(t *testing.T) {
+	buffer := bytes.Buffer{}
+	if err := png.Encode(&buffer, image.NewGray(image.Rect(0, 0, MaxDimension+1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Decode(buffer.Bytes()); err != ErrTooLarge {
+		t.Fatalf("Expected ErrTooLarge but got %v", err)
+	}
+}
+
+func TestBlurHash(t *testing.T) {
+	hash := BlurHash(testImage(120, 80))
+	// 1 for the size, 1 for the maximum, 4 for the average colour and 2 for each of the other 11 components
+	if len(hash) != 28 {
+		t.Fatalf("Expected a 28 character hash but got %q", hash)
+	}
+	// 4x3 components always starts with an L
+	if hash[0] != 'L' {
+		t.Fatalf("Expected the hash to start with L but got %q", hash)
+	}
+
+	solid := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for i := range solid.Pix {
+		solid.Pix[i] = 255
+	}
+	// The average colour of a plain white image is white
+	if hash = BlurHash(solid); hash[2:6] != encodeBase83(0xffffff, 4) {
+		t.Fatalf("The hash of a white image is wrong: %q", hash)
+	}
+}
@@ -0,0 +1,210 @@
+// Package moderation: checks text against a list of banned words. Each word says what happens to text that uses it,
+// it can be masked out, have the whole text rejected or get it flagged for a moderator to look at.
+package moderation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Action string
+
+const (
+	ActionMask   Action = "mask"
+	ActionReject Action = "reject"
+	ActionFlag   Action = "flag"
+
+	// Mask replaces every banned word that is masked, whatever its length
+	Mask = "****"
+
+	MaxWordLength = 50
+)
+
+// ParseAction checks that action is one of the actions a word can have
+func ParseAction(action string) (Action, bool) {
+	switch Action(action) {
+	case ActionMask, ActionReject, ActionFlag:
+		return Action(action), true
+	}
+	return "", false
+}
+
+// Rule is a banned word and what to do about it
+type Rule struct {
+	Word   string
+	Action Action
+}
+
+// ValidWord checks that a word is a single word of at most MaxWordLength characters, which is all the filter can match
+func ValidWord(word string) bool {
+	if utf8.RuneCountInString(word) > MaxWordLength || key([]rune(word)) == "" {
+		return false
+	}
+	for _, r := range word {
+		if !isWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseWordList reads one word per line, optionally followed by its action (mask when it's left out).
+// Blank lines and lines starting with # are skipped
+func ParseWordList(reader io.Reader) ([]Rule, error) {
+	rules := []Rule{}
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) > 2 || !ValidWord(fields[0]) {
+			return nil, fmt.Errorf("line %d: expected a single word and an optional action", line)
+		}
+
+		rule := Rule{Word: fields[0], Action: ActionMask}
+		if len(fields) == 2 {
+			action, ok := ParseAction(fields[1])
+			if !ok {
+				return nil, fmt.Errorf("line %d: unknown action '%s'", line, fields[1])
+			}
+			rule.Action = action
+		}
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// Filter is safe to use from many goroutines while its rules are being replaced
+type Filter struct {
+	mu    sync.RWMutex
+	rules ruleSet
+}
+
+// ruleSet has each rule by its key, and by its squashed key for words that have been stretched out
+type ruleSet struct {
+	exact    map[string]Rule
+	squashed map[string]Rule
+}
+
+func (rules ruleSet) find(word []rune) (Rule, bool) {
+	k := key(word)
+	if rule, ok := rules.exact[k]; ok {
+		return rule, true
+	}
+	// Only words with a letter repeated 3 or more times are squashed, so that "as" doesn't turn into "ass"
+	if squashed, stretched := squash(k); stretched {
+		rule, ok := rules.squashed[squashed]
+		return rule, ok
+	}
+	return Rule{}, false
+}
+
+// NewFilter makes a filter with the given rules, later rules win when two of them are for the same word
+func NewFilter(rules []Rule) *Filter {
+	filter := &Filter{}
+	filter.SetRules(rules)
+	return filter
+}
+
+// SetRules replaces every rule in the filter
+func (filter *Filter) SetRules(rules []Rule) {
+	set := ruleSet{exact: map[string]Rule{}, squashed: map[string]Rule{}}
+	for _, rule := range rules {
+		if k := key([]rune(rule.Word)); k != "" {
+			set.exact[k] = rule
+			squashed, _ := squash(k)
+			set.squashed[squashed] = rule
+		}
+	}
+
+	filter.mu.Lock()
+	defer filter.mu.Unlock()
+	filter.rules = set
+}
+
+// Result is what the filter made of some text. Text has the masked words replaced with Mask, Flagged has the banned
+// words (as they are in the rules) that should get it looked at
+type Result struct {
+	Text     string
+	Rejected bool
+	Flagged  []string
+}
+
+// Check looks for banned words in text. Words only match as a whole, so a banned word inside a longer one is fine,
+// but they're matched whatever their case, accents, look-alike letters, leetspeak or letters stretched out 3 or more
+// times
+func (filter *Filter) Check(text string) Result {
+	filter.mu.RLock()
+	rules := filter.rules
+	filter.mu.RUnlock()
+
+	runes := []rune(text)
+	result := Result{Flagged: []string{}}
+	var masked strings.Builder
+	written := 0
+	for i := 0; i < len(runes); i++ {
+		if !isWordRune(runes[i]) {
+			continue
+		}
+		end := i
+		for end < len(runes) && isWordRune(runes[end]) {
+			end++
+		}
+
+		if start, stop, rule, ok := match(rules, runes, i, end); ok {
+			switch rule.Action {
+			case ActionMask:
+				masked.WriteString(string(runes[written:start]))
+				masked.WriteString(Mask)
+				written = stop
+			case ActionReject:
+				result.Rejected = true
+			case ActionFlag:
+				result.Flagged = append(result.Flagged, rule.Word)
+			}
+		}
+		i = end - 1
+	}
+	masked.WriteString(string(runes[written:]))
+	result.Text = masked.String()
+	return result
+}
+
+// match tries the word between start and end, then the same word without any symbols on either end of it. That way
+// the ! in "k!ll" can stand in for an i, but "kerfuffle!" is still just kerfuffle
+func match(rules ruleSet, runes []rune, start, end int) (int, int, Rule, bool) {
+	trimmedStart := start
+	for trimmedStart < end && isSymbol(runes[trimmedStart]) {
+		trimmedStart++
+	}
+	trimmedEnd := end
+	for trimmedEnd > trimmedStart && isSymbol(runes[trimmedEnd-1]) {
+		trimmedEnd--
+	}
+
+	for _, bounds := range [][2]int{{start, end}, {start, trimmedEnd}, {trimmedStart, end}, {trimmedStart, trimmedEnd}} {
+		if bounds[0] >= bounds[1] {
+			continue
+		}
+		if rule, ok := rules.find(runes[bounds[0]:bounds[1]]); ok {
+			return bounds[0], bounds[1], rule, true
+		}
+	}
+	return 0, 0, Rule{}, false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || unicode.Is(unicode.Cf, r) || isSymbol(r)
+}
+
+// isSymbol is true for the punctuation that leetspeak uses in place of letters
+func isSymbol(r rune) bool {
+	_, ok := leet[r]
+	return ok && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
@@ -0,0 +1,122 @@
+package moderation
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMasking(t *testing.T) {
+	filter := NewFilter([]Rule{{"kerfuffle", ActionMask}, {"sharbert", ActionMask}, {"fornax", ActionMask}})
+
+	inputs := []string{
+		"This is a kerfuffle opinion I need to share with the world",
+		"KERFUFFLE! what a Sharbert.",
+		"(fornax) and \"kerfuffle\"",
+		"k3rfuffl3 and $h4rb3rt and f0rn@x",
+		"kérfüffle, ｋｅｒｆｕｆｆｌｅ and k\u0435rfuffle with a Cyrillic \u0435",
+		"ker\u200bfuffle and kerfuuuuffle",
+		"kerfufflecake and unsharberted are fine",
+		"no banned words at all",
+	}
+	outputs := []string{
+		"This is a **** opinion I need to share with the world",
+		"****! what a ****.",
+		"(****) and \"****\"",
+		"**** and **** and ****",
+		"****, **** and **** with a Cyrillic \u0435",
+		"**** and ****",
+		"kerfufflecake and unsharberted are fine",
+		"no banned words at all",
+	}
+
+	for i := range inputs {
+		result := filter.Check(inputs[i])
+		if result.Text != outputs[i] {
+			t.Fatalf("Masked text doesn't match for '%v': '%v' != '%v'", inputs[i], result.Text, outputs[i])
+		}
+		if result.Rejected || len(result.Flagged) != 0 {
+			t.Fatalf("Masked words shouldn't reject or flag '%v'", inputs[i])
+		}
+	}
+}
+
+func TestOrdinaryWords(t *testing.T) {
+	filter := NewFilter([]Rule{{"ass", ActionMask}, {"hell", ActionReject}})
+
+	for _, text := range []string{"as far as I know", "hello there", "hei and heil", "he11o", "assassin", "class"} {
+		result := filter.Check(text)
+		if result.Text != text || result.Rejected {
+			t.Fatalf("Expected '%v' to be left alone: %+v", text, result)
+		}
+	}
+
+	if result := filter.Check("asssss, h3ll and heeeellll"); result.Text != "****, h3ll and heeeellll" || !result.Rejected {
+		t.Fatalf("Expected the banned words to be caught: %+v", result)
+	}
+}
+
+func TestRejectAndFlag(t *testing.T) {
+	filter := NewFilter([]Rule{{"kerfuffle", ActionReject}, {"fornax", ActionFlag}, {"sharbert", ActionFlag}})
+
+	result := filter.Check("Kerfuffle!")
+	if !result.Rejected || result.Text != "Kerfuffle!" {
+		t.Fatalf("Expected the text to be rejected as it is: %+v", result)
+	}
+
+	result = filter.Check("FORNAX and sh@rbert")
+	if result.Rejected || result.Text != "FORNAX and sh@rbert" {
+		t.Fatalf("Expected the text to be left alone: %+v", result)
+	}
+	if !slices.Equal(result.Flagged, []string{"fornax", "sharbert"}) {
+		t.Fatalf("Flagged words don't match: %v", result.Flagged)
+	}
+}
+
+func TestSetRules(t *testing.T) {
+	filter := NewFilter([]Rule{{"fornax", ActionMask}})
+	if filter.Check("fornax").Text != Mask {
+		t.Fatal("Expected fornax to be masked")
+	}
+
+	// The later rule for the same word wins
+	filter.SetRules([]Rule{{"fornax", ActionMask}, {"FORNAX", ActionReject}})
+	if !filter.Check("fornax").Rejected {
+		t.Fatal("Expected fornax to be rejected")
+	}
+
+	filter.SetRules(nil)
+	if result := filter.Check("fornax"); result.Text != "fornax" || result.Rejected {
+		t.Fatalf("Expected fornax to be allowed: %+v", result)
+	}
+}
+
+func TestParseWordList(t *testing.T) {
+	rules, err := ParseWordList(strings.NewReader("# banned words\nkerfuffle\n\nsharbert reject\n  fornax   flag\n"))
+	if err != nil {
+		t.Fatalf("Couldn't parse the word list: %s", err)
+	}
+	expected := []Rule{{"kerfuffle", ActionMask}, {"sharbert", ActionReject}, {"fornax", ActionFlag}}
+	if !slices.Equal(rules, expected) {
+		t.Fatalf("Rules don't match: %v != %v", rules, expected)
+	}
+
+	for _, bad := range []string{"kerfuffle ban", "two words mask", "!?. mask"} {
+		if _, err = ParseWordList(strings.NewReader(bad)); err == nil {
+			t.Fatalf("Expected '%v' to be rejected", bad)
+		}
+	}
+}
+
+func TestValidWord(t *testing.T) {
+	for _, word := range []string{"kerfuffle", "k3rfuffl3", "café"} {
+		if !ValidWord(word) {
+			t.Fatalf("Expected '%v' to be valid", word)
+		}
+	}
+	for _, word := range []string{"", "two words", "kerfuffle!?", strings.Repeat("a", MaxWordLength+1)} {
+		if ValidWord(word) {
+			t.Fatalf("Expected '%v' to be invalid", word)
+		}
+	}
+}
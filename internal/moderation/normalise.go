@@ -0,0 +1,121 @@
+package moderation
+
+import (
+	"strings"
+	"unicode"
+)
+
+// accents maps letters with accents to the plain letter. It only covers the Latin letters people actually use to get
+// around filters, anything else is left as it is
+var accents = foldTable(map[rune]string{
+	'a': "àáâãäåāăą",
+	'c': "çćĉċč",
+	'd': "ďđ",
+	'e': "èéêëēĕėęě",
+	'g': "ĝğġģ",
+	'h': "ĥħ",
+	'i': "ìíîïĩīĭįı",
+	'j': "ĵ",
+	'k': "ķ",
+	'l': "ĺļľŀł",
+	'n': "ñńņňŉ",
+	'o': "òóôõöøōŏő",
+	'r': "ŕŗř",
+	's': "śŝşšſ",
+	't': "ţťŧ",
+	'u': "ùúûüũūŭůűų",
+	'w': "ŵ",
+	'y': "ýÿŷ",
+	'z': "źżž",
+})
+
+// lookalikes maps Cyrillic and Greek letters to the Latin letter they can pass for
+var lookalikes = foldTable(map[rune]string{
+	'a': "аα",
+	'c': "с",
+	'd': "ԁ",
+	'e': "еε",
+	'h': "һ",
+	'i': "іι",
+	'j': "ј",
+	'k': "кκ",
+	'o': "оο",
+	'p': "рρ",
+	's': "ѕ",
+	't': "τ",
+	'u': "υ",
+	'v': "ν",
+	'x': "хχ",
+	'y': "у",
+})
+
+// leet maps the digits and symbols leetspeak uses to the letter they stand for. Letters are always left as themselves,
+// otherwise ordinary words would end up matching banned ones
+var leet = map[rune]rune{
+	'0': 'o',
+	'1': 'i', '!': 'i', '|': 'i',
+	'3': 'e', '€': 'e',
+	'4': 'a', '@': 'a',
+	'5': 's', '$': 's',
+	'7': 't', '+': 't',
+	'8': 'b',
+	'9': 'g',
+}
+
+func foldTable(letters map[rune]string) map[rune]rune {
+	table := map[rune]rune{}
+	for plain, variants := range letters {
+		for _, variant := range variants {
+			table[variant] = plain
+		}
+	}
+	return table
+}
+
+// key is the form a word is matched on. Invisible characters and combining accents are dropped, full width and
+// look-alike letters become ASCII and leetspeak is undone
+func key(word []rune) string {
+	var builder strings.Builder
+	for _, r := range word {
+		if unicode.IsMark(r) || unicode.Is(unicode.Cf, r) {
+			continue
+		}
+		// Full width forms like ｋ are in the same order as ASCII
+		if r >= '！' && r <= '～' {
+			r -= '！' - '!'
+		}
+		r = unicode.ToLower(r)
+		if plain, ok := accents[r]; ok {
+			r = plain
+		}
+		if plain, ok := lookalikes[r]; ok {
+			r = plain
+		}
+		if plain, ok := leet[r]; ok {
+			r = plain
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+// squash turns every run of the same letter in a key into one letter. It also reports whether the key was stretched
+// out, that is if any letter repeated at least 3 times in a row
+func squash(k string) (string, bool) {
+	var builder strings.Builder
+	var previous rune
+	run, stretched := 0, false
+	for _, r := range k {
+		if r == previous {
+			run++
+			if run >= 3 {
+				stretched = true
+			}
+			continue
+		}
+		builder.WriteRune(r)
+		previous = r
+		run = 1
+	}
+	return builder.String(), stretched
+}
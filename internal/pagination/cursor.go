@@ -0,0 +1,92 @@
+// Package pagination: builds and reads the opaque cursors used to page through lists such as the chirp timeline.
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Cursor points at a single row. Rows are ordered by CreatedAt and then ID so that two rows made in the same instant still have a stable order
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func EncodeCursor(cursor Cursor) string {
+	raw := cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + cursor.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(encoded string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("cursor isn't valid base64: %w", err)
+	}
+
+	timestamp, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return Cursor{}, errors.New("cursor is missing its separator")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("cursor has an invalid timestamp: %w", err)
+	}
+	cursorID, err := uuid.Parse(id)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("cursor has an invalid id: %w", err)
+	}
+
+	return Cursor{CreatedAt: createdAt, ID: cursorID}, nil
+}
+
+// ParseLimit turns the ?limit= query parameter into a usable page size, an empty string gives DefaultLimit
+func ParseLimit(limit string) (int, error) {
+	if limit == "" {
+		return DefaultLimit, nil
+	}
+
+	n, err := strconv.Atoi(limit)
+	if err != nil {
+		return 0, fmt.Errorf("limit must be a number: %v", limit)
+	}
+	if n < 1 || n > MaxLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+	}
+	return n, nil
+}
+
+// NextLink copies the request URL with one query parameter swapped out, ready to be used in a `Link: <...>; rel="next"` header
+func NextLink(requestURL *url.URL, key, value string) string {
+	query := requestURL.Query()
+	query.Set(key, value)
+	return fmt.Sprintf(`<%s?%s>; rel="next"`, requestURL.Path, query.Encode())
+}
+
+// ParseOffset turns the ?offset= query parameter into a number of rows to skip, an empty string gives 0
+func ParseOffset(offset string) (int, error) {
+	if offset == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(offset)
+	if err != nil {
+		return 0, fmt.Errorf("offset must be a number: %v", offset)
+	}
+	if n < 0 {
+		return 0, errors.New("offset can't be negative")
+	}
+	return n, nil
+}
@@ -0,0 +1,77 @@
+package pagination
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := Cursor{
+		CreatedAt: time.Date(2025, time.March, 14, 15, 9, 26, 535897000, time.UTC),
+		ID:        uuid.New(),
+	}
+
+	decoded, err := DecodeCursor(EncodeCursor(cursor))
+	if err != nil {
+		t.Fatalf("An error occured whilst decoding the cursor: %s", err)
+	}
+	if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID {
+		t.Fatalf("The cursor changed after a round trip: %v != %v", decoded, cursor)
+	}
+}
+
+func TestBadCursor(t *testing.T) {
+	inputs := []string{
+		"",
+		"not base64!",
+		EncodeCursor(Cursor{})[:10],
+		"bm8tc2VwYXJhdG9y",
+	}
+
+	for _, input := range inputs {
+		if _, err := DecodeCursor(input); err == nil {
+			t.Fatalf("Expected an error when decoding '%v'", input)
+		}
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	type Output struct {
+		limit        int
+		returnsError bool
+	}
+	inputs := []string{"", "5", "0", "101", "abc", "10abc"}
+	outputs := []Output{
+		{DefaultLimit, false},
+		{5, false},
+		{0, true},
+		{0, true},
+		{0, true},
+		{0, true},
+	}
+
+	for i := range inputs {
+		limit, err := ParseLimit(inputs[i])
+		if limit != outputs[i].limit {
+			t.Fatalf("Limits don't match: %v != %v", limit, outputs[i].limit)
+		} else if (err != nil) != outputs[i].returnsError {
+			t.Fatalf("Expected return error to be %v but we actually got %v", outputs[i].returnsError, err != nil)
+		}
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	requestURL, err := url.Parse("/api/chirps?author_id=123&after=old&limit=5")
+	if err != nil {
+		t.Fatalf("An error occured whilst parsing the url: %s", err)
+	}
+
+	link := NextLink(requestURL, "after", "new")
+	expected := `</api/chirps?after=new&author_id=123&limit=5>; rel="next"`
+	if link != expected {
+		t.Fatalf("Links don't match: %v != %v", link, expected)
+	}
+}
@@ -0,0 +1,130 @@
+// Package pubsub: an in-process hub that fans events (like a new chirp) out to everyone listening for them.
+package pubsub
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventChirpCreated = "chirp.created"
+	EventChirpUpdated = "chirp.updated"
+	EventChirpDeleted = "chirp.deleted"
+	// These are only for RecipientID, they aren't saved so they have no ID
+	EventNotification   = "notification.created"
+	EventMessageCreated = "message.created"
+)
+
+type Event struct {
+	ID       int64     `json:"id"`
+	Type     string    `json:"type"`
+	AuthorID uuid.UUID `json:"author_id"`
+	// RecipientID is set on events that are meant for one user
+	RecipientID uuid.UUID `json:"recipient_id,omitzero"`
+	Hashtags    []string  `json:"hashtags,omitempty"`
+	// EmbeddedAuthorIDs are the authors of the chirps that a rechirp or quote embeds
+	EmbeddedAuthorIDs []uuid.UUID     `json:"embedded_author_ids,omitempty"`
+	Data              json.RawMessage `json:"data"`
+	// Origin is the hub that the event was first published on, so that a hub can ignore its own events when they come back from another server
+	Origin string `json:"origin"`
+}
+
+// Subscription receives every event that passes its filter. If the subscriber falls too far behind, the channel is
+// closed instead of slowing down everyone else, the subscriber can then catch up from the database and subscribe again
+type Subscription struct {
+	events chan Event
+	filter func(Event) bool
+}
+
+func (subscription *Subscription) Events() <-chan Event {
+	return subscription.events
+}
+
+type Hub struct {
+	mu          sync.Mutex
+	id          string
+	bufferSize  int
+	subscribers map[*Subscription]struct{}
+	forwarders  []func(Event)
+}
+
+func NewHub(bufferSize int) *Hub {
+	return &Hub{
+		id:          uuid.NewString(),
+		bufferSize:  bufferSize,
+		subscribers: map[*Subscription]struct{}{},
+	}
+}
+
+// ID identifies this hub, it is stamped onto every event published here
+func (hub *Hub) ID() string {
+	return hub.id
+}
+
+// Subscribe starts receiving events, a nil filter receives everything
+func (hub *Hub) Subscribe(filter func(Event) bool) *Subscription {
+	subscription := &Subscription{
+		events: make(chan Event, hub.bufferSize),
+		filter: filter,
+	}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	hub.subscribers[subscription] = struct{}{}
+	return subscription
+}
+
+// Unsubscribe stops the subscription and closes its channel, it is safe to call more than once
+func (hub *Hub) Unsubscribe(subscription *Subscription) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	hub.remove(subscription)
+}
+
+// OnPublish registers a function that is given every event published on this hub, e.g. to send it to other servers
+func (hub *Hub) OnPublish(forward func(Event)) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	hub.forwarders = append(hub.forwarders, forward)
+}
+
+// Publish sends an event to the local subscribers and the OnPublish functions
+func (hub *Hub) Publish(event Event) {
+	event.Origin = hub.id
+	hub.Deliver(event)
+
+	hub.mu.Lock()
+	forwarders := hub.forwarders
+	hub.mu.Unlock()
+	for _, forward := range forwarders {
+		forward(event)
+	}
+}
+
+// Deliver only sends an event to the local subscribers. It is for events that were published somewhere else
+func (hub *Hub) Deliver(event Event) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for subscription := range hub.subscribers {
+		if subscription.filter != nil && !subscription.filter(event) {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			hub.remove(subscription)
+		}
+	}
+}
+
+// remove expects hub.mu to be held
+func (hub *Hub) remove(subscription *Subscription) {
+	if _, ok := hub.subscribers[subscription]; !ok {
+		return
+	}
+	delete(hub.subscribers, subscription)
+	close(subscription.events)
+}
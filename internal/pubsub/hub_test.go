@@ -0,0 +1,57 @@
+package pubsub
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPublishFiltersEvents(t *testing.T) {
+	hub := NewHub(10)
+	author := uuid.New()
+
+	everything := hub.Subscribe(nil)
+	onlyAuthor := hub.Subscribe(func(event Event) bool { return event.AuthorID == author })
+
+	hub.Publish(Event{ID: 1, Type: EventChirpCreated, AuthorID: uuid.New()})
+	hub.Publish(Event{ID: 2, Type: EventChirpCreated, AuthorID: author})
+
+	if len(everything.Events()) != 2 {
+		t.Fatalf("Expected 2 events but got %v", len(everything.Events()))
+	}
+	if len(onlyAuthor.Events()) != 1 {
+		t.Fatalf("Expected 1 event but got %v", len(onlyAuthor.Events()))
+	}
+	if event := <-onlyAuthor.Events(); event.ID != 2 || event.Origin != hub.ID() {
+		t.Fatalf("Got the wrong event: %v", event)
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	hub := NewHub(1)
+	subscription := hub.Subscribe(nil)
+
+	hub.Publish(Event{ID: 1})
+	hub.Publish(Event{ID: 2})
+
+	if event, ok := <-subscription.Events(); !ok || event.ID != 1 {
+		t.Fatalf("Expected the first event to be delivered: %v, %v", event, ok)
+	}
+	if _, ok := <-subscription.Events(); ok {
+		t.Fatalf("Expected the channel to be closed after the buffer filled up")
+	}
+	hub.Unsubscribe(subscription)
+}
+
+func TestForwardersOnlySeePublishedEvents(t *testing.T) {
+	hub := NewHub(10)
+	forwarded := []Event{}
+	hub.OnPublish(func(event Event) { forwarded = append(forwarded, event) })
+
+	hub.Publish(Event{ID: 1})
+	hub.Deliver(Event{ID: 2, Origin: "another server"})
+
+	if len(forwarded) != 1 || forwarded[0].ID != 1 {
+		t.Fatalf("Expected only the published event to be forwarded: %v", forwarded)
+	}
+}
@@ -0,0 +1,57 @@
+// Package search: turns what a user types into the search box into a Postgres tsquery.
+package search
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// BuildTSQuery converts a search string into the syntax expected by to_tsquery.
+// Words are ANDed together, "quoted words" become a phrase and a trailing * makes a prefix search (chir* matches chirp and chirpy).
+// Any other punctuation is thrown away so that the user can't break the tsquery syntax
+func BuildTSQuery(raw string) (string, error) {
+	terms := []string{}
+
+	for i, segment := range strings.Split(raw, `"`) {
+		words := cleanWords(segment)
+		if len(words) == 0 {
+			continue
+		}
+
+		// Every odd segment was between a pair of quotes
+		if i%2 == 1 {
+			terms = append(terms, "("+strings.Join(words, " <-> ")+")")
+			continue
+		}
+		terms = append(terms, words...)
+	}
+
+	if len(terms) == 0 {
+		return "", errors.New("search query doesn't contain any words")
+	}
+	return strings.Join(terms, " & "), nil
+}
+
+func cleanWords(segment string) []string {
+	words := []string{}
+	for _, field := range strings.Fields(segment) {
+		prefix := strings.HasSuffix(field, "*")
+
+		word := strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return unicode.ToLower(r)
+			}
+			return -1
+		}, field)
+		if word == "" {
+			continue
+		}
+
+		if prefix {
+			word += ":*"
+		}
+		words = append(words, word)
+	}
+	return words
+}
@@ -0,0 +1,37 @@
+package search
+
+import "testing"
+
+func TestBuildTSQuery(t *testing.T) {
+	type Output struct {
+		query        string
+		returnsError bool
+	}
+	inputs := []string{
+		"hello",
+		"Hello World",
+		`"good morning" chirpy`,
+		"chir*",
+		"don't & break | it!",
+		"",
+		`!!! "" ***`,
+	}
+	outputs := []Output{
+		{"hello", false},
+		{"hello & world", false},
+		{"(good <-> morning) & chirpy", false},
+		{"chir:*", false},
+		{"dont & break & it", false},
+		{"", true},
+		{"", true},
+	}
+
+	for i := range inputs {
+		query, err := BuildTSQuery(inputs[i])
+		if query != outputs[i].query {
+			t.Fatalf("Queries don't match: %v != %v", query, outputs[i].query)
+		} else if (err != nil) != outputs[i].returnsError {
+			t.Fatalf("Expected return error to be %v but we actually got %v", outputs[i].returnsError, err != nil)
+		}
+	}
+}
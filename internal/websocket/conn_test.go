@@ -0,0 +1,128 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAcceptKey(t *testing.T) {
+	// The example from RFC 6455
+	if key := AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); key != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Got the wrong accept key: %v", key)
+	}
+}
+
+// dial does the client side of the handshake against a server that echoes every message back
+func dial(t *testing.T) (net.Conn, *bufio.Reader) {
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		conn, err := Upgrade(response, request)
+		if err != nil {
+			http.Error(response, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer conn.Close()
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(messageType, data)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	io.WriteString(conn, "GET / HTTP/1.1\r\nHost: chirpy\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n"+
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("Couldn't read the handshake: %s", err)
+	}
+	if response.StatusCode != http.StatusSwitchingProtocols || response.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("The handshake went wrong: %v %v", response.StatusCode, response.Header)
+	}
+	return conn, reader
+}
+
+func writeClientFrame(conn net.Conn, fin bool, opcode byte, payload []byte) {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame := []byte{first, 0x80 | byte(len(payload))}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	conn.Write(frame)
+}
+
+func readServerFrame(t *testing.T, reader *bufio.Reader) (byte, []byte) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		t.Fatalf("Couldn't read a frame: %s", err)
+	}
+	payload := make([]byte, header[1]&0x7f)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		t.Fatalf("Couldn't read a frame: %s", err)
+	}
+	return header[0] & 0x0f, payload
+}
+
+func TestEchoFragmentedMessage(t *testing.T) {
+	conn, reader := dial(t)
+
+	writeClientFrame(conn, false, TextMessage, []byte("Hello, "))
+	// A ping in the middle of a fragmented message is allowed
+	writeClientFrame(conn, true, PingMessage, []byte("are you there?"))
+	writeClientFrame(conn, true, ContinuationMessage, []byte("Chirpy"))
+
+	if opcode, payload := readServerFrame(t, reader); opcode != PongMessage || string(payload) != "are you there?" {
+		t.Fatalf("Expected a pong but got %v %q", opcode, payload)
+	}
+	if opcode, payload := readServerFrame(t, reader); opcode != TextMessage || string(payload) != "Hello, Chirpy" {
+		t.Fatalf("Expected the message back but got %v %q", opcode, payload)
+	}
+}
+
+func TestCloseHandshake(t *testing.T) {
+	conn, reader := dial(t)
+
+	writeClientFrame(conn, true, CloseMessage, binary.BigEndian.AppendUint16(nil, CloseGoingAway))
+	opcode, payload := readServerFrame(t, reader)
+	if opcode != CloseMessage || binary.BigEndian.Uint16(payload) != CloseGoingAway {
+		t.Fatalf("Expected the close to be echoed but got %v %v", opcode, payload)
+	}
+}
+
+func TestUnmaskedFrameIsRejected(t *testing.T) {
+	conn, reader := dial(t)
+
+	conn.Write([]byte{0x80 | TextMessage, 2, 'h', 'i'})
+	opcode, payload := readServerFrame(t, reader)
+	if opcode != CloseMessage || binary.BigEndian.Uint16(payload) != CloseProtocolError {
+		t.Fatalf("Expected a protocol error but got %v %v", opcode, payload)
+	}
+}
+
+func TestCloseErrorMessage(t *testing.T) {
+	var err error = &CloseError{Code: CloseNormal, Reason: "bye"}
+	closeErr := &CloseError{}
+	if !errors.As(err, &closeErr) || closeErr.Reason != "bye" {
+		t.Fatalf("Expected a CloseError: %v", err)
+	}
+}
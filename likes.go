@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/pagination"
+)
+
+type Liker struct {
+	UserID  uuid.UUID `json:"user_id"`
+	LikedAt time.Time `json:"liked_at"`
+}
+
+type LikerList struct {
+	Count int64   `json:"count"`
+	Users []Liker `json:"users"`
+}
+
+func (config *apiConfig) likeChirpHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(request.PathValue("ChirpID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing that UUID", err, http.StatusBadRequest)
+		return
+	}
+
+	chirp, err := config.dbQueries.GetChirpForViewer(request.Context(), database.GetChirpForViewerParams{
+		ID:       chirpID,
+		ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(response, request, "Chirp not found", err, http.StatusNotFound)
+		return
+	}
+	if chirp.TombstonedAt.Valid {
+		respondWithError(response, request, "You can't like a deleted chirp", nil, http.StatusBadRequest)
+		return
+	}
+
+	if err = config.dbQueries.LikeChirp(request.Context(), database.LikeChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	}); err != nil {
+		respondWithError(response, request, "There was an error liking the chirp", err, http.StatusBadRequest)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func (config *apiConfig) unlikeChirpHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(request.PathValue("ChirpID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing that UUID", err, http.StatusBadRequest)
+		return
+	}
+
+	rowsAffected, err := config.dbQueries.UnlikeChirp(request.Context(), database.UnlikeChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error unliking the chirp", err, http.StatusBadRequest)
+		return
+	}
+	if rowsAffected == 0 {
+		respondWithError(response, request, "You haven't liked that chirp", nil, http.StatusNotFound)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func (config *apiConfig) getLikersHandler(response http.ResponseWriter, request *http.Request) {
+	chirpID, err := uuid.Parse(request.PathValue("ChirpID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing that UUID", err, http.StatusBadRequest)
+		return
+	}
+
+	query := request.URL.Query()
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(response, request, err.Error(), err, http.StatusBadRequest)
+		return
+	}
+	offset, err := pagination.ParseOffset(query.Get("offset"))
+	if err != nil {
+		respondWithError(response, request, err.Error(), err, http.StatusBadRequest)
+		return
+	}
+
+	if _, err = config.dbQueries.GetChirpForViewer(request.Context(), database.GetChirpForViewerParams{
+		ID:       chirpID,
+		ViewerID: getViewerFromHeader(request.Header, config.secret),
+	}); err != nil {
+		respondWithError(response, request, "Chirp not found", err, http.StatusNotFound)
+		return
+	}
+
+	count, err := config.dbQueries.CountLikes(request.Context(), chirpID)
+	if err != nil {
+		respondWithError(response, request, "There was an error counting the likes", err, http.StatusBadRequest)
+		return
+	}
+	rows, err := config.dbQueries.ListLikers(request.Context(), database.ListLikersParams{
+		ChirpID: chirpID,
+		Limit:   int32(limit + 1),
+		Offset:  int32(offset),
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching the likes", err, http.StatusBadRequest)
+		return
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		response.Header().Set("Link", pagination.NextLink(request.URL, "offset", strconv.Itoa(offset+limit)))
+	}
+
+	likers := LikerList{Count: count, Users: []Liker{}}
+	for _, row := range rows {
+		likers.Users = append(likers.Users, Liker{row.UserID, row.CreatedAt})
+	}
+	respondWithJSON(response, request, likers, http.StatusOK)
+}
+
+// addLikes fills in like_count and liked_by_me for every chirp using one query, no matter how many chirps there are
+func (config *apiConfig) addLikes(ctx context.Context, viewerID uuid.NullUUID, chirps []*Chirp) error {
+	chirpIDs := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		chirpIDs = append(chirpIDs, chirp.ID)
+	}
+
+	summaries, err := config.dbQueries.GetLikeSummaries(ctx, database.GetLikeSummariesParams{
+		ViewerID: viewerID,
+		ChirpIds: chirpIDs,
+	})
+	if err != nil {
+		return err
+	}
+
+	summariesByChirp := map[uuid.UUID]database.GetLikeSummariesRow{}
+	for _, summary := range summaries {
+		summariesByChirp[summary.ChirpID] = summary
+	}
+	for _, chirp := range chirps {
+		summary := summariesByChirp[chirp.ID]
+		chirp.LikeCount = summary.LikeCount
+		chirp.LikedByMe = summary.LikedByViewer
+	}
+	return nil
+}
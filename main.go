@@ -1,22 +1,38 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"sync/atomic"
+	"time"
 
 	dotenv "github.com/joho/godotenv"
 	_ "github.com/lib/pq"
+	"github.com/vilebile17/chirpy/internal/blobstore"
 	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/moderation"
+	"github.com/vilebile17/chirpy/internal/pubsub"
 )
 
 type apiConfig struct {
 	fileServerHits atomic.Int32
+	db             *sql.DB
 	dbQueries      *database.Queries
 	secret         string
 	apiKey         string
+	trending       trendingCache
+	hub            *pubsub.Hub
+	blobs          blobstore.BlobStore
+	// How long after posting a chirp can still be edited, Chirpy Red users get redEditWindow
+	editWindow    time.Duration
+	redEditWindow time.Duration
+	// The banned words filter, wordList is the part of it that came from the word list file
+	moderation *moderation.Filter
+	wordList   []moderation.Rule
 }
 
 func (config *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
@@ -27,6 +43,19 @@ func (config *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
 	return http.HandlerFunc(coolFunc)
 }
 
+// durationFromEnv reads a duration like "30m" from the environment, using fallback when it isn't set
+func durationFromEnv(name string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s isn't a valid duration: %w", name, err)
+	}
+	return duration, nil
+}
+
 func main() {
 	if err := dotenv.Load(); err != nil {
 		log.Fatal(err)
@@ -38,26 +67,141 @@ func main() {
 		log.Fatal(err)
 	}
 
-	cfg := apiConfig{dbQueries: database.New(db)}
+	cfg := apiConfig{db: db, dbQueries: database.New(db), hub: pubsub.NewHub(streamBufferSize)}
 	cfg.secret = os.Getenv("SECRET")
 	cfg.apiKey = os.Getenv("POLKA_KEY")
+
+	mediaRoot := os.Getenv("MEDIA_ROOT")
+	if mediaRoot == "" {
+		mediaRoot = "./media"
+	}
+	blobs, err := blobstore.NewFileSystem(mediaRoot, "/media")
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg.blobs = blobs
+
+	if cfg.editWindow, err = durationFromEnv("CHIRP_EDIT_WINDOW", 15*time.Minute); err != nil {
+		log.Fatal(err)
+	}
+	if cfg.redEditWindow, err = durationFromEnv("CHIRP_EDIT_WINDOW_RED", time.Hour); err != nil {
+		log.Fatal(err)
+	}
+
+	if cfg.wordList, err = loadWordList(os.Getenv("MODERATION_WORD_LIST")); err != nil {
+		log.Fatal(err)
+	}
+	cfg.moderation = moderation.NewFilter(cfg.wordList)
+	if err = cfg.reloadModerationWords(context.Background()); err != nil {
+		log.Printf("Error loading the banned words: %s\n", err)
+	}
 	const port = "8080"
 
 	mux := http.NewServeMux()
 	mux.Handle("/", cfg.middlewareMetricsInc(http.FileServer(http.Dir("./website/"))))
+	mux.Handle("GET /media/", middlewareCacheForever(http.StripPrefix("/media/", http.FileServer(http.Dir(mediaRoot)))))
 	mux.HandleFunc("GET /api/healthz", healthzHandler)
 	mux.HandleFunc("GET /admin/metrics", http.HandlerFunc(cfg.readServerHits))
 	mux.HandleFunc("POST /admin/reset", http.HandlerFunc(cfg.resetHandler))
+	mux.HandleFunc("GET /admin/moderation/words", cfg.getModerationWordsHandler)
+	mux.HandleFunc("PUT /admin/moderation/words/{Word}", cfg.setModerationWordHandler)
+	mux.HandleFunc("DELETE /admin/moderation/words/{Word}", cfg.deleteModerationWordHandler)
+	mux.HandleFunc("GET /admin/moderation/flags", cfg.getFlaggedChirpsHandler)
+	mux.HandleFunc("DELETE /admin/moderation/flags/{ChirpID}", cfg.dismissFlagHandler)
 	mux.HandleFunc("POST /api/chirps", cfg.createChirpHandler)
 	mux.HandleFunc("GET /api/chirps", cfg.getAllChirpsHandler)
+	mux.HandleFunc("GET /api/chirps/search", cfg.searchChirpsHandler)
 	mux.HandleFunc("GET /api/chirps/{ChirpID}", cfg.getChirpHandler)
+	mux.HandleFunc("GET /api/chirps/{ChirpID}/thread", cfg.getThreadHandler)
+	mux.HandleFunc("PATCH /api/chirps/{ChirpID}", cfg.editChirpHandler)
+	mux.HandleFunc("GET /api/chirps/{ChirpID}/revisions", cfg.getRevisionsHandler)
 	mux.HandleFunc("DELETE /api/chirps/{ChirpID}", cfg.deleteChirpHandler)
+	mux.HandleFunc("POST /api/chirps/{ChirpID}/likes", cfg.likeChirpHandler)
+	mux.HandleFunc("DELETE /api/chirps/{ChirpID}/likes", cfg.unlikeChirpHandler)
+	mux.HandleFunc("GET /api/chirps/{ChirpID}/likes", cfg.getLikersHandler)
+	mux.HandleFunc("POST /api/chirps/{ChirpID}/poll/votes", cfg.votePollHandler)
+	mux.HandleFunc("PUT /api/chirps/{ChirpID}/sensitive", cfg.setSensitiveHandler)
+	mux.HandleFunc("POST /api/chirps/{ChirpID}/bookmark", cfg.bookmarkHandler)
+	mux.HandleFunc("DELETE /api/chirps/{ChirpID}/bookmark", cfg.removeBookmarkHandler)
+	mux.HandleFunc("POST /api/chirps/{ChirpID}/rechirp", cfg.rechirpHandler)
+	mux.HandleFunc("DELETE /api/chirps/{ChirpID}/rechirp", cfg.undoRechirpHandler)
+	mux.HandleFunc("GET /api/users/me/bookmarks", cfg.getBookmarksHandler)
+	mux.HandleFunc("POST /api/collections", cfg.createCollectionHandler)
+	mux.HandleFunc("GET /api/collections", cfg.getCollectionsHandler)
+	mux.HandleFunc("PUT /api/collections/{CollectionID}", cfg.renameCollectionHandler)
+	mux.HandleFunc("DELETE /api/collections/{CollectionID}", cfg.deleteCollectionHandler)
+	mux.HandleFunc("GET /api/collections/{CollectionID}/chirps", cfg.getCollectionChirpsHandler)
+	mux.HandleFunc("POST /api/collections/{CollectionID}/chirps", cfg.addToCollectionHandler)
+	mux.HandleFunc("DELETE /api/collections/{CollectionID}/chirps/{ChirpID}", cfg.removeFromCollectionHandler)
+	mux.HandleFunc("POST /api/drafts", cfg.createDraftHandler(false))
+	mux.HandleFunc("GET /api/drafts", cfg.listDraftsHandler(false))
+	mux.HandleFunc("PUT /api/drafts/{DraftID}", cfg.updateDraftHandler(false))
+	mux.HandleFunc("DELETE /api/drafts/{DraftID}", cfg.deleteDraftHandler(false))
+	mux.HandleFunc("POST /api/scheduled", cfg.createDraftHandler(true))
+	mux.HandleFunc("GET /api/scheduled", cfg.listDraftsHandler(true))
+	mux.HandleFunc("PUT /api/scheduled/{DraftID}", cfg.updateDraftHandler(true))
+	mux.HandleFunc("DELETE /api/scheduled/{DraftID}", cfg.deleteDraftHandler(true))
+	mux.HandleFunc("POST /api/media", cfg.uploadMediaHandler)
+	mux.HandleFunc("PUT /api/media/{MediaID}", cfg.updateMediaHandler)
 	mux.HandleFunc("POST /api/users", cfg.registerUser)
 	mux.HandleFunc("PUT /api/users", cfg.updateDetailsHandler)
 	mux.HandleFunc("POST /api/login", cfg.loginHandler)
 	mux.HandleFunc("POST /api/refresh", cfg.refreshHandler)
 	mux.HandleFunc("POST /api/revoke", cfg.revokeHandler)
 	mux.HandleFunc("POST /api/polka/webhooks", cfg.upgradePlanHandler)
+	mux.HandleFunc("POST /api/users/{UserID}/follow", cfg.followHandler)
+	mux.HandleFunc("DELETE /api/users/{UserID}/follow", cfg.unfollowHandler)
+	mux.HandleFunc("GET /api/users/{UserID}/followers", cfg.getFollowersHandler)
+	mux.HandleFunc("GET /api/users/{UserID}/following", cfg.getFollowingHandler)
+	mux.HandleFunc("POST /api/users/{UserID}/block", cfg.blockHandler)
+	mux.HandleFunc("DELETE /api/users/{UserID}/block", cfg.unblockHandler)
+	mux.HandleFunc("POST /api/users/{UserID}/mute", cfg.muteHandler)
+	mux.HandleFunc("DELETE /api/users/{UserID}/mute", cfg.unmuteHandler)
+	mux.HandleFunc("GET /api/users/me/blocks", cfg.blockListHandler("blocks", false, cfg.getBlockedEntries))
+	mux.HandleFunc("GET /api/users/me/blocks/export", cfg.blockListHandler("blocks", true, cfg.getBlockedEntries))
+	mux.HandleFunc("GET /api/users/me/mutes", cfg.blockListHandler("mutes", false, cfg.getMutedEntries))
+	mux.HandleFunc("GET /api/users/me/mutes/export", cfg.blockListHandler("mutes", true, cfg.getMutedEntries))
+	mux.HandleFunc("GET /api/users/me/mentions", cfg.getMyMentionsHandler)
+	mux.HandleFunc("GET /api/users/me/trash", cfg.getTrashHandler)
+	mux.HandleFunc("POST /api/users/me/trash/{ChirpID}/restore", cfg.restoreChirpHandler)
+	mux.HandleFunc("GET /api/users/{handle}", cfg.getProfileHandler)
+	mux.HandleFunc("PUT /api/users/me/profile", cfg.updateProfileHandler)
+	mux.HandleFunc("PUT /api/users/me/handle", cfg.changeHandleHandler)
+	mux.HandleFunc("PUT /api/users/me/avatar", cfg.uploadImageHandler(avatarImage))
+	mux.HandleFunc("DELETE /api/users/me/avatar", cfg.deleteImageHandler(avatarImage))
+	mux.HandleFunc("PUT /api/users/me/banner", cfg.uploadImageHandler(bannerImage))
+	mux.HandleFunc("DELETE /api/users/me/banner", cfg.deleteImageHandler(bannerImage))
+	mux.HandleFunc("PUT /api/users/me/pinned", cfg.pinChirpHandler)
+	mux.HandleFunc("DELETE /api/users/me/pinned", cfg.unpinChirpHandler)
+	mux.HandleFunc("GET /api/users/me/settings", cfg.getSettingsHandler)
+	mux.HandleFunc("PUT /api/users/me/settings", cfg.updateSettingsHandler)
+	mux.HandleFunc("GET /api/timeline", cfg.getTimelineHandler)
+	mux.HandleFunc("GET /api/stream", cfg.streamHandler)
+	mux.HandleFunc("GET /api/ws", cfg.wsHandler)
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", cfg.getHashtagChirpsHandler)
+	mux.HandleFunc("GET /api/trending", cfg.getTrendingHandler)
+	mux.HandleFunc("GET /api/notifications", cfg.getNotificationsHandler)
+	mux.HandleFunc("GET /api/notifications/unread_count", cfg.unreadNotificationsHandler)
+	mux.HandleFunc("POST /api/notifications/read", cfg.readAllNotificationsHandler)
+	mux.HandleFunc("POST /api/notifications/{NotificationID}/read", cfg.readNotificationHandler)
+
+	mux.HandleFunc("POST /api/conversations", cfg.createConversationHandler)
+	mux.HandleFunc("GET /api/conversations", cfg.getConversationsHandler)
+	mux.HandleFunc("DELETE /api/conversations/{ConversationID}", cfg.deleteConversationHandler)
+	mux.HandleFunc("GET /api/conversations/{ConversationID}/messages", cfg.getMessagesHandler)
+	mux.HandleFunc("POST /api/conversations/{ConversationID}/messages", cfg.sendMessageHandler)
+	mux.HandleFunc("DELETE /api/conversations/{ConversationID}/messages/{MessageID}", cfg.deleteMessageHandler)
+	mux.HandleFunc("POST /api/conversations/{ConversationID}/read", cfg.readConversationHandler)
+
+	cfg.hub.OnPublish(cfg.notifyOtherServers)
+	go cfg.listenForStreamEvents(context.Background(), dbURL)
+	go cfg.streamEventCleaner(context.Background())
+	go cfg.trendingWorker(context.Background())
+	go cfg.mediaCleaner(context.Background())
+	go cfg.scheduledChirpPublisher(context.Background())
+	go cfg.chirpPurger(context.Background())
+	go cfg.expiredChirpSweeper(context.Background())
+	go cfg.moderationReloader(context.Background())
 
 	server := http.Server{
 		Addr:    ":" + port,
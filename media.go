@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/images"
+)
+
+const (
+	maxMediaPerChirp = 4
+	maxAltTextLength = 1000
+	// maxMediaSide keeps photos straight off a phone camera down to a sensible size
+	maxMediaSide = 2048
+	// orphanedMediaMaxAge is how long an upload can sit around without being attached to a chirp
+	orphanedMediaMaxAge = 24 * time.Hour
+)
+
+type Media struct {
+	ID          uuid.UUID `json:"id"`
+	URL         string    `json:"url"`
+	ContentType string    `json:"content_type"`
+	Width       int32     `json:"width"`
+	Height      int32     `json:"height"`
+	AltText     string    `json:"alt_text"`
+	BlurHash    string    `json:"blurhash"`
+}
+
+func mediaKey(media database.Media) string {
+	extension := "jpg"
+	if media.ContentType == "image/gif" {
+		extension = "gif"
+	}
+	return fmt.Sprintf("media/%s/%s.%s", media.UserID, media.ID, extension)
+}
+
+func (config *apiConfig) mediaFromDatabase(media database.Media) Media {
+	return Media{
+		ID:          media.ID,
+		URL:         config.blobs.URL(mediaKey(media)),
+		ContentType: media.ContentType,
+		Width:       media.Width,
+		Height:      media.Height,
+		AltText:     media.AltText,
+		BlurHash:    media.Blurhash,
+	}
+}
+
+// validateAltText writes the error response itself when the alt text is too long
+func validateAltText(response http.ResponseWriter, request *http.Request, altText string) (string, bool) {
+	altText = strings.TrimSpace(altText)
+	if utf8.RuneCountInString(altText) > maxAltTextLength {
+		respondWithError(response, request, fmt.Sprintf("alt_text can't be longer than %d characters", maxAltTextLength), nil, http.StatusBadRequest)
+		return "", false
+	}
+	return altText, true
+}
+
+// uploadMediaHandler is the first step of adding images to a chirp. The upload goes in the multipart "image" field
+// (with an optional "alt_text" field) and the ID it returns goes in media_ids when creating the chirp.
+// Photos are encoded again as JPEGs so their EXIF data is dropped, GIFs are stored as they are so they keep moving
+func (config *apiConfig) uploadMediaHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	data, img, format, ok := readImageUpload(response, request)
+	if !ok {
+		return
+	}
+	altText, ok := validateAltText(response, request, request.FormValue("alt_text"))
+	if !ok {
+		return
+	}
+
+	contentType := "image/jpeg"
+	buffer := bytes.NewBuffer(data)
+	if format == "gif" {
+		contentType = "image/gif"
+	} else {
+		img = images.FitWithin(img, maxMediaSide)
+		buffer = &bytes.Buffer{}
+		if err = images.EncodeJPEG(buffer, img); err != nil {
+			respondWithError(response, request, "There was an error saving the image", err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	params := database.CreateMediaParams{
+		ID:          uuid.New(),
+		UserID:      userID,
+		ContentType: contentType,
+		Width:       int32(img.Bounds().Dx()),
+		Height:      int32(img.Bounds().Dy()),
+		AltText:     altText,
+		Blurhash:    images.BlurHash(img),
+	}
+	key := mediaKey(database.Media{ID: params.ID, UserID: userID, ContentType: contentType})
+	if err = config.blobs.Put(request.Context(), key, buffer); err != nil {
+		respondWithError(response, request, "There was an error saving the image", err, http.StatusInternalServerError)
+		return
+	}
+
+	media, err := config.dbQueries.CreateMedia(request.Context(), params)
+	if err != nil {
+		if deleteErr := config.blobs.Delete(request.Context(), key); deleteErr != nil {
+			log.Printf("Error deleting unsaved media: %s\n", deleteErr)
+		}
+		respondWithError(response, request, "There was an error saving the image", err, http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(response, request, config.mediaFromDatabase(media), http.StatusCreated)
+}
+
+// updateMediaHandler changes the alt text, which also works after the media is attached to a chirp
+func (config *apiConfig) updateMediaHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	mediaID, err := uuid.Parse(request.PathValue("MediaID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing the UUID of the media", err, http.StatusBadRequest)
+		return
+	}
+
+	type IncomingJSON struct {
+		AltText string `json:"alt_text"`
+	}
+	incomingjson := IncomingJSON{}
+	if err = json.NewDecoder(request.Body).Decode(&incomingjson); err != nil {
+		respondWithError(response, request, "Something went wrong, required format: {'alt_text': 'ALT_TEXT'}", err, http.StatusBadRequest)
+		return
+	}
+	altText, ok := validateAltText(response, request, incomingjson.AltText)
+	if !ok {
+		return
+	}
+
+	media, err := config.dbQueries.UpdateMediaAltText(request.Context(), database.UpdateMediaAltTextParams{
+		ID:      mediaID,
+		UserID:  userID,
+		AltText: altText,
+	})
+	if err != nil {
+		respondWithError(response, request, "Couldn't find that media", err, http.StatusNotFound)
+		return
+	}
+	respondWithJSON(response, request, config.mediaFromDatabase(media), http.StatusOK)
+}
+
+// parseMediaIDs checks the media_ids of a new chirp, writing the error response itself if they're no good
+func parseMediaIDs(response http.ResponseWriter, request *http.Request, rawIDs []string) ([]uuid.UUID, bool) {
+	if len(rawIDs) > maxMediaPerChirp {
+		respondWithError(response, request, fmt.Sprintf("A chirp can have at most %d images", maxMediaPerChirp), nil, http.StatusBadRequest)
+		return nil, false
+	}
+
+	ids := []uuid.UUID{}
+	seen := map[uuid.UUID]bool{}
+	for _, rawID := range rawIDs {
+		id, err := uuid.Parse(rawID)
+		if err != nil {
+			respondWithError(response, request, "There was an error parsing the media_ids", err, http.StatusBadRequest)
+			return nil, false
+		}
+		if seen[id] {
+			respondWithError(response, request, "The same media can't be added twice", nil, http.StatusBadRequest)
+			return nil, false
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids, true
+}
+
+// addMedia fills in the images of every chirp with a single query
+func (config *apiConfig) addMedia(ctx context.Context, chirps []*Chirp) error {
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		if !chirp.Deleted {
+			ids = append(ids, chirp.ID)
+		}
+	}
+
+	rows, err := config.dbQueries.GetMediaForChirps(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	media := map[uuid.UUID][]Media{}
+	for _, row := range rows {
+		media[row.ChirpID.UUID] = append(media[row.ChirpID.UUID], config.mediaFromDatabase(row))
+	}
+	for _, chirp := range chirps {
+		chirp.Media = media[chirp.ID]
+	}
+	return nil
+}
+
+// mediaCleaner deletes uploads that never made it onto a chirp, and the media of deleted chirps
+func (config *apiConfig) mediaCleaner(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		orphans, err := config.dbQueries.DeleteOrphanedMedia(ctx, orphanedMediaMaxAge.Seconds())
+		if err != nil {
+			log.Printf("Error deleting orphaned media: %s\n", err)
+		}
+		for _, orphan := range orphans {
+			if err = config.blobs.Delete(ctx, mediaKey(orphan)); err != nil {
+				log.Printf("Error deleting the file of orphaned media %s: %s\n", orphan.ID, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/entities"
+	"github.com/vilebile17/chirpy/internal/pagination"
+)
+
+// ChirpEntity tells clients which part of the body to turn into a link. Start and End count unicode code points.
+// Target is the tag for a hashtag and the user's ID for a mention
+type ChirpEntity struct {
+	Type   string `json:"type"`
+	Start  int    `json:"start"`
+	End    int    `json:"end"`
+	Target string `json:"target"`
+}
+
+// mentionUsers records who is mentioned in a chirp, it should be called in the same transaction that created the chirp.
+// Handles that don't belong to anyone are skipped and stay as plain text. It returns the users that should be sent a
+// notification once the transaction is committed: the ones that weren't mentioned before, leaving out the author
+// and anyone that has blocked or been blocked by them, or has muted them
+func mentionUsers(ctx context.Context, queries *database.Queries, chirp database.Chirp) ([]uuid.UUID, error) {
+	handles := entities.Mentions(chirp.Body)
+	if len(handles) == 0 {
+		return nil, nil
+	}
+
+	return queries.AddChirpMentions(ctx, database.AddChirpMentionsParams{
+		Handles: handles,
+		ChirpID: chirp.ID,
+	})
+}
+
+// notifyMentions lets the users that mentionUsers returned know that they were mentioned
+func (config *apiConfig) notifyMentions(ctx context.Context, chirp database.Chirp, userIDs []uuid.UUID) {
+	for _, userID := range userIDs {
+		if err := config.emitNotification(ctx, userID, NotificationMentioned, map[string]string{
+			"chirp_id":  chirp.ID.String(),
+			"author_id": chirp.UserID.String(),
+		}); err != nil {
+			log.Printf("Error sending a mention notification: %s\n", err)
+		}
+	}
+}
+
+// addEntities works out the entities of every chirp. Mentions are matched against who was mentioned when the chirp
+// was written so that they keep pointing at the same person even if the handle changes hands later
+func (config *apiConfig) addEntities(ctx context.Context, chirps []*Chirp) error {
+	chirpIDs := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		chirpIDs = append(chirpIDs, chirp.ID)
+	}
+
+	mentions, err := config.dbQueries.GetChirpMentions(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+	mentioned := map[uuid.UUID]map[string]uuid.UUID{}
+	for _, mention := range mentions {
+		if mentioned[mention.ChirpID] == nil {
+			mentioned[mention.ChirpID] = map[string]uuid.UUID{}
+		}
+		mentioned[mention.ChirpID][mention.Handle] = mention.UserID
+	}
+
+	for _, chirp := range chirps {
+		chirp.Entities = []ChirpEntity{}
+		for _, entity := range entities.Parse(chirp.Body) {
+			target := entity.Value
+			if entity.Type == entities.TypeMention {
+				userID, ok := mentioned[chirp.ID][entity.Value]
+				if !ok {
+					continue
+				}
+				target = userID.String()
+			}
+			chirp.Entities = append(chirp.Entities, ChirpEntity{entity.Type, entity.Start, entity.End, target})
+		}
+	}
+	return nil
+}
+
+func (config *apiConfig) getMyMentionsHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	query := request.URL.Query()
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(response, request, err.Error(), err, http.StatusBadRequest)
+		return
+	}
+
+	params := database.GetMentionedChirpsParams{
+		UserID:   userID,
+		RowLimit: int32(limit + 1),
+	}
+	if before := query.Get("before"); before != "" {
+		cursor, err := pagination.DecodeCursor(before)
+		if err != nil {
+			respondWithError(response, request, "The 'before' cursor is invalid", err, http.StatusBadRequest)
+			return
+		}
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	sqlChirps, err := config.dbQueries.GetMentionedChirps(request.Context(), params)
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching your mentions", err, http.StatusBadRequest)
+		return
+	}
+
+	if len(sqlChirps) > limit {
+		sqlChirps = sqlChirps[:limit]
+		last := sqlChirps[len(sqlChirps)-1]
+		next := pagination.EncodeCursor(pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		response.Header().Set("Link", pagination.NextLink(request.URL, "before", next))
+	}
+
+	chirps := []Chirp{}
+	for _, chirp := range sqlChirps {
+		chirps = append(chirps, chirpFromDatabase(chirp))
+	}
+	if err = config.hydrateChirps(request.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirpPointers(chirps)); err != nil {
+		respondWithError(response, request, "There was an error loading your mentions", err, http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(response, request, chirps, http.StatusOK)
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/moderation"
+	"github.com/vilebile17/chirpy/internal/pagination"
+)
+
+// moderationReloadInterval is how often the banned words are read from the database again, so that changes made on
+// another server are picked up
+const moderationReloadInterval = time.Minute
+
+// ModerationWord is a banned word. Source is "file" for the words in the word list file, which can't be changed
+// through the API, and "database" for the rest
+type ModerationWord struct {
+	Word   string `json:"word"`
+	Action string `json:"action"`
+	Source string `json:"source"`
+}
+
+// FlaggedChirp is a chirp waiting for a moderator because of the words in FlaggedWords
+type FlaggedChirp struct {
+	Chirp
+	FlaggedWords []string  `json:"flagged_words"`
+	FlaggedAt    time.Time `json:"flagged_at"`
+}
+
+// loadWordList reads the banned words file at path, there just aren't any when path is empty
+func loadWordList(path string) ([]moderation.Rule, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rules, err := moderation.ParseWordList(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// reloadModerationWords gives the filter the words from the file and the database. The database wins when a word is
+// in both
+func (config *apiConfig) reloadModerationWords(ctx context.Context) error {
+	words, err := config.dbQueries.ListModerationWords(ctx)
+	if err != nil {
+		return err
+	}
+
+	rules := append([]moderation.Rule{}, config.wordList...)
+	for _, word := range words {
+		rules = append(rules, moderation.Rule{Word: word.Word, Action: moderation.Action(word.Action)})
+	}
+	config.moderation.SetRules(rules)
+	return nil
+}
+
+func (config *apiConfig) moderationReloader(ctx context.Context) {
+	ticker := time.NewTicker(moderationReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := config.reloadModerationWords(ctx); err != nil {
+			log.Printf("Error reloading the banned words: %s\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// moderateTexts runs each text through the banned words filter, masking them in place. It gives back the words that
+// should get the chirp flagged for review, or a chirpError if any of the texts can't be posted at all
+func (config *apiConfig) moderateTexts(texts ...*string) ([]string, error) {
+	flagged := []string{}
+	seen := map[string]bool{}
+	for _, text := range texts {
+		result := config.moderation.Check(*text)
+		if result.Rejected {
+			return nil, &chirpError{"Your chirp uses words that aren't allowed", http.StatusUnprocessableEntity}
+		}
+		*text = result.Text
+
+		for _, word := range result.Flagged {
+			if !seen[word] {
+				seen[word] = true
+				flagged = append(flagged, word)
+			}
+		}
+	}
+	return flagged, nil
+}
+
+// flagChirp puts a chirp in the review queue if moderateTexts flagged any of its words
+func flagChirp(ctx context.Context, queries *database.Queries, chirpID uuid.UUID, words []string) error {
+	if len(words) == 0 {
+		return nil
+	}
+	return queries.FlagChirp(ctx, database.FlagChirpParams{ChirpID: chirpID, Words: words})
+}
+
+// getModerator writes the error response itself when the user isn't logged in or isn't a moderator
+func (config *apiConfig) getModerator(response http.ResponseWriter, request *http.Request) (uuid.UUID, bool) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return uuid.Nil, false
+	}
+
+	user, err := config.dbQueries.GetUserByID(request.Context(), userID)
+	if err != nil {
+		respondWithError(response, request, "Couldn't find the user...", err, http.StatusNotFound)
+		return uuid.Nil, false
+	}
+	if !user.IsModerator {
+		respondWithError(response, request, "Only moderators can do that", nil, http.StatusForbidden)
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func (config *apiConfig) getModerationWordsHandler(response http.ResponseWriter, request *http.Request) {
+	if _, ok := config.getModerator(response, request); !ok {
+		return
+	}
+
+	rows, err := config.dbQueries.ListModerationWords(request.Context())
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching the banned words", err, http.StatusInternalServerError)
+		return
+	}
+
+	words := []ModerationWord{}
+	for _, rule := range config.wordList {
+		words = append(words, ModerationWord{Word: rule.Word, Action: string(rule.Action), Source: "file"})
+	}
+	for _, row := range rows {
+		words = append(words, ModerationWord{Word: row.Word, Action: row.Action, Source: "database"})
+	}
+	respondWithJSON(response, request, words, http.StatusOK)
+}
+
+// setModerationWordHandler bans {Word}, or changes what happens to chirps that use it
+func (config *apiConfig) setModerationWordHandler(response http.ResponseWriter, request *http.Request) {
+	if _, ok := config.getModerator(response, request); !ok {
+		return
+	}
+
+	word := strings.ToLower(request.PathValue("Word"))
+	if !moderation.ValidWord(word) {
+		respondWithError(response, request, fmt.Sprintf("Banned words have to be a single word of at most %d characters", moderation.MaxWordLength), nil, http.StatusBadRequest)
+		return
+	}
+
+	type IncomingJSON struct {
+		Action string `json:"action"`
+	}
+	incomingjson := IncomingJSON{}
+	if err := json.NewDecoder(request.Body).Decode(&incomingjson); err != nil {
+		respondWithError(response, request, "Something went wrong, required format {'action': 'mask' | 'reject' | 'flag'}", err, http.StatusBadRequest)
+		return
+	}
+	action, ok := moderation.ParseAction(incomingjson.Action)
+	if !ok {
+		respondWithError(response, request, "action must be 'mask', 'reject' or 'flag'", nil, http.StatusBadRequest)
+		return
+	}
+
+	row, err := config.dbQueries.SetModerationWord(request.Context(), database.SetModerationWordParams{
+		Word:   word,
+		Action: string(action),
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error saving the banned word", err, http.StatusInternalServerError)
+		return
+	}
+	if err = config.reloadModerationWords(request.Context()); err != nil {
+		log.Printf("Error reloading the banned words: %s\n", err)
+	}
+	respondWithJSON(response, request, ModerationWord{Word: row.Word, Action: row.Action, Source: "database"}, http.StatusOK)
+}
+
+func (config *apiConfig) deleteModerationWordHandler(response http.ResponseWriter, request *http.Request) {
+	if _, ok := config.getModerator(response, request); !ok {
+		return
+	}
+
+	rowsAffected, err := config.dbQueries.DeleteModerationWord(request.Context(), strings.ToLower(request.PathValue("Word")))
+	if err != nil {
+		respondWithError(response, request, "There was an error removing the banned word", err, http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected == 0 {
+		respondWithError(response, request, "That word isn't banned in the database, it might be in the word list file", nil, http.StatusNotFound)
+		return
+	}
+	if err = config.reloadModerationWords(request.Context()); err != nil {
+		log.Printf("Error reloading the banned words: %s\n", err)
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+// getFlaggedChirpsHandler lists the chirps waiting for review, most recently flagged first. It pages with ?limit= and
+// ?before=
+func (config *apiConfig) getFlaggedChirpsHandler(response http.ResponseWriter, request *http.Request) {
+	moderatorID, ok := config.getModerator(response, request)
+	if !ok {
+		return
+	}
+
+	limit, beforeFlaggedAt, beforeID, ok := parseSavedPage(response, request)
+	if !ok {
+		return
+	}
+
+	rows, err := config.dbQueries.ListFlaggedChirps(request.Context(), database.ListFlaggedChirpsParams{
+		BeforeCreatedAt: beforeFlaggedAt,
+		BeforeID:        beforeID,
+		RowLimit:        int32(limit + 1),
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching the flagged chirps", err, http.StatusInternalServerError)
+		return
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		next := pagination.EncodeCursor(pagination.Cursor{CreatedAt: last.FlaggedAt, ID: last.Chirp.ID})
+		response.Header().Set("Link", pagination.NextLink(request.URL, "before", next))
+	}
+
+	flagged := []FlaggedChirp{}
+	for _, row := range rows {
+		flagged = append(flagged, FlaggedChirp{
+			Chirp:        chirpFromDatabase(row.Chirp),
+			FlaggedWords: row.Words,
+			FlaggedAt:    row.FlaggedAt,
+		})
+	}
+	chirps := make([]*Chirp, 0, len(flagged))
+	for i := range flagged {
+		chirps = append(chirps, &flagged[i].Chirp)
+	}
+	if err = config.hydrateChirps(request.Context(), uuid.NullUUID{UUID: moderatorID, Valid: true}, chirps); err != nil {
+		respondWithError(response, request, "There was an error loading the chirps", err, http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(response, request, flagged, http.StatusOK)
+}
+
+// dismissFlagHandler takes {ChirpID} out of the review queue once a moderator has looked at it
+func (config *apiConfig) dismissFlagHandler(response http.ResponseWriter, request *http.Request) {
+	if _, ok := config.getModerator(response, request); !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(request.PathValue("ChirpID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing that UUID", err, http.StatusBadRequest)
+		return
+	}
+
+	rowsAffected, err := config.dbQueries.DismissFlag(request.Context(), chirpID)
+	if err != nil {
+		respondWithError(response, request, "There was an error dismissing the flag", err, http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected == 0 {
+		respondWithError(response, request, "That chirp isn't flagged", nil, http.StatusNotFound)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
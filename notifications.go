@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/pagination"
+	"github.com/vilebile17/chirpy/internal/pubsub"
+)
+
+const (
+	NotificationPlanUpgraded    = "plan_upgraded"
+	NotificationPasswordChanged = "password_changed"
+	NotificationEmailChanged    = "email_changed"
+	NotificationNewLogin        = "new_login"
+	// NotificationScheduledChirpFailed is sent when a scheduled chirp can't go out, it's turned back into a draft
+	NotificationScheduledChirpFailed = "scheduled_chirp_failed"
+	// NotificationMentioned is sent when someone mentions the user in a new chirp, or edits a chirp to mention them
+	NotificationMentioned = "mentioned"
+)
+
+type Notification struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+	ReadAt    *time.Time      `json:"read_at"`
+}
+
+// emitNotification lets a user know that something happened to their account or chirps.
+// data can be anything that encodes to a JSON object, it is handed to the client as it is.
+// It is also pushed to the user's live connections straight away, so emit after committing any transaction
+func (config *apiConfig) emitNotification(ctx context.Context, userID uuid.UUID, notificationType string, data any) error {
+	if data == nil {
+		data = struct{}{}
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	sqlNotification, err := config.dbQueries.CreateNotification(ctx, database.CreateNotificationParams{
+		UserID: userID,
+		Type:   notificationType,
+		Data:   encoded,
+	})
+	if err != nil {
+		return err
+	}
+
+	live, err := json.Marshal(notificationFromDatabase(sqlNotification))
+	if err != nil {
+		return err
+	}
+	config.hub.Publish(pubsub.Event{
+		Type:        pubsub.EventNotification,
+		RecipientID: userID,
+		Data:        live,
+	})
+	return nil
+}
+
+func notificationFromDatabase(notification database.Notification) Notification {
+	result := Notification{
+		ID:        notification.ID,
+		Type:      notification.Type,
+		Data:      notification.Data,
+		CreatedAt: notification.CreatedAt,
+	}
+	if notification.ReadAt.Valid {
+		result.ReadAt = &notification.ReadAt.Time
+	}
+	return result
+}
+
+// getNotificationsHandler lists the newest notifications first. Supports ?type=, ?unread=true, ?limit= and ?before=
+func (config *apiConfig) getNotificationsHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	query := request.URL.Query()
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(response, request, err.Error(), err, http.StatusBadRequest)
+		return
+	}
+
+	params := database.ListNotificationsParams{
+		UserID:     userID,
+		UnreadOnly: query.Get("unread") == "true",
+		RowLimit:   int32(limit + 1),
+	}
+	if notificationType := query.Get("type"); notificationType != "" {
+		params.Type = sql.NullString{String: notificationType, Valid: true}
+	}
+	if before := query.Get("before"); before != "" {
+		cursor, err := pagination.DecodeCursor(before)
+		if err != nil {
+			respondWithError(response, request, "The 'before' cursor is invalid", err, http.StatusBadRequest)
+			return
+		}
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	sqlNotifications, err := config.dbQueries.ListNotifications(request.Context(), params)
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching your notifications", err, http.StatusBadRequest)
+		return
+	}
+
+	if len(sqlNotifications) > limit {
+		sqlNotifications = sqlNotifications[:limit]
+		last := sqlNotifications[len(sqlNotifications)-1]
+		next := pagination.EncodeCursor(pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		response.Header().Set("Link", pagination.NextLink(request.URL, "before", next))
+	}
+
+	notifications := []Notification{}
+	for _, notification := range sqlNotifications {
+		notifications = append(notifications, notificationFromDatabase(notification))
+	}
+	respondWithJSON(response, request, notifications, http.StatusOK)
+}
+
+func (config *apiConfig) readNotificationHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	notificationID, err := uuid.Parse(request.PathValue("NotificationID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing that UUID", err, http.StatusBadRequest)
+		return
+	}
+
+	rowsAffected, err := config.dbQueries.MarkNotificationRead(request.Context(), database.MarkNotificationReadParams{
+		ID:     notificationID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error marking the notification as read", err, http.StatusBadRequest)
+		return
+	}
+	if rowsAffected == 0 {
+		respondWithError(response, request, "Notification not found", nil, http.StatusNotFound)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func (config *apiConfig) readAllNotificationsHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	if err = config.dbQueries.MarkAllNotificationsRead(request.Context(), userID); err != nil {
+		respondWithError(response, request, "There was an error marking your notifications as read", err, http.StatusBadRequest)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func (config *apiConfig) unreadNotificationsHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	count, err := config.dbQueries.CountUnreadNotifications(request.Context(), userID)
+	if err != nil {
+		respondWithError(response, request, "There was an error counting your notifications", err, http.StatusBadRequest)
+		return
+	}
+
+	respondWithJSON(response, request, struct {
+		Count int64 `json:"count"`
+	}{
+		count,
+	}, http.StatusOK)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+)
+
+const (
+	maxPinnedChirps    = 1
+	maxRedPinnedChirps = 3
+)
+
+// pinChirpHandler pins one of the user's own chirps to their profile. When they're out of pins the oldest one is
+// unpinned to make room, so for most people this just swaps their pinned chirp
+func (config *apiConfig) pinChirpHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	type IncomingJSON struct {
+		ChirpID string `json:"chirp_id"`
+	}
+	incomingjson := IncomingJSON{}
+	if err = json.NewDecoder(request.Body).Decode(&incomingjson); err != nil {
+		respondWithError(response, request, "Something went wrong, required format: {'chirp_id': 'CHIRP_ID'}", err, http.StatusBadRequest)
+		return
+	}
+	chirpID, err := uuid.Parse(incomingjson.ChirpID)
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing that UUID", err, http.StatusBadRequest)
+		return
+	}
+
+	chirp, err := config.dbQueries.GetOneChirp(request.Context(), chirpID)
+	if err != nil {
+		respondWithError(response, request, "Chirp not found", err, http.StatusNotFound)
+		return
+	}
+
+	if chirp.UserID != userID {
+		respondWithError(response, request, "You can't pin this chirp, incorrect JWT token", err, http.StatusForbidden)
+		return
+	}
+	if chirp.TombstonedAt.Valid {
+		respondWithError(response, request, "You can't pin a deleted chirp", nil, http.StatusBadRequest)
+		return
+	}
+	if chirp.RechirpOfID.Valid {
+		respondWithError(response, request, "Rechirps can't be pinned", nil, http.StatusBadRequest)
+		return
+	}
+
+	user, err := config.dbQueries.GetUserByID(request.Context(), userID)
+	if err != nil {
+		respondWithError(response, request, "Couldn't find the user...", err, http.StatusNotFound)
+		return
+	}
+	maxPins := maxPinnedChirps
+	if user.IsChirpyRed {
+		maxPins = maxRedPinnedChirps
+	}
+
+	tx, err := config.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(response, request, "There was an error pinning the chirp", err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	queries := config.dbQueries.WithTx(tx)
+
+	if err = queries.PinChirp(request.Context(), database.PinChirpParams{UserID: userID, ChirpID: chirpID}); err != nil {
+		respondWithError(response, request, "There was an error pinning the chirp", err, http.StatusInternalServerError)
+		return
+	}
+	if err = queries.TrimPinnedChirps(request.Context(), database.TrimPinnedChirpsParams{UserID: userID, Keep: int32(maxPins)}); err != nil {
+		respondWithError(response, request, "There was an error unpinning your older chirps", err, http.StatusInternalServerError)
+		return
+	}
+	if err = tx.Commit(); err != nil {
+		respondWithError(response, request, "There was an error pinning the chirp", err, http.StatusInternalServerError)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+// unpinChirpHandler unpins ?chirp_id=, or every pinned chirp when it's left out
+func (config *apiConfig) unpinChirpHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	var rowsAffected int64
+	if rawChirpID := request.URL.Query().Get("chirp_id"); rawChirpID != "" {
+		chirpID, err := uuid.Parse(rawChirpID)
+		if err != nil {
+			respondWithError(response, request, "There was an error parsing that UUID", err, http.StatusBadRequest)
+			return
+		}
+		rowsAffected, err = config.dbQueries.UnpinChirp(request.Context(), database.UnpinChirpParams{UserID: userID, ChirpID: chirpID})
+	} else {
+		rowsAffected, err = config.dbQueries.UnpinAllChirps(request.Context(), userID)
+	}
+	if err != nil {
+		respondWithError(response, request, "There was an error unpinning the chirp", err, http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected == 0 {
+		respondWithError(response, request, "You don't have that chirp pinned", nil, http.StatusNotFound)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+// getPinnedChirps gets the chirps an author has pinned, newest pin first, leaving out any hidden by a block. Mutes
+// aren't applied: pins are only shown with ?author_id=, and ListChirps* doesn't apply mutes when the viewer asks for an
+// author by name either, so the pins show up exactly when the rest of the author's chirps do
+func (config *apiConfig) getPinnedChirps(ctx context.Context, authorID uuid.UUID, viewerID uuid.NullUUID) ([]Chirp, error) {
+	sqlChirps, err := config.dbQueries.GetPinnedChirps(ctx, database.GetPinnedChirpsParams{
+		UserID:   authorID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	chirps := []Chirp{}
+	for _, sqlChirp := range sqlChirps {
+		chirp := chirpFromDatabase(sqlChirp)
+		chirp.Pinned = true
+		chirps = append(chirps, chirp)
+	}
+	return chirps, nil
+}
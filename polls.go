@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+)
+
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionLength = 25
+	minPollDuration     = 5 * time.Minute
+	maxPollDuration     = 7 * 24 * time.Hour
+)
+
+// NewPoll is the poll part of a new chirp
+type NewPoll struct {
+	Options         []string `json:"options"`
+	DurationMinutes int      `json:"duration_minutes"`
+}
+
+// Poll goes inline on a chirp. The votes are left out until the viewer has voted or the poll has closed, so that
+// nobody just picks whatever is winning
+type Poll struct {
+	Options    []PollOption `json:"options"`
+	TotalVotes *int64       `json:"total_votes,omitempty"`
+	ClosesAt   time.Time    `json:"closes_at"`
+	Closed     bool         `json:"closed"`
+	// VotedFor is the position of the option the viewer voted for
+	VotedFor *int32 `json:"voted_for,omitempty"`
+}
+
+type PollOption struct {
+	Position int32  `json:"position"`
+	Text     string `json:"text"`
+	Votes    *int64 `json:"votes,omitempty"`
+}
+
+// validatePoll writes the error response itself when the poll isn't allowed, and gives back the tidied up options
+func validatePoll(response http.ResponseWriter, request *http.Request, poll NewPoll) ([]string, time.Duration, bool) {
+	if len(poll.Options) < minPollOptions || len(poll.Options) > maxPollOptions {
+		respondWithError(response, request, fmt.Sprintf("A poll needs %d to %d options", minPollOptions, maxPollOptions), nil, http.StatusBadRequest)
+		return nil, 0, false
+	}
+
+	options := []string{}
+	seen := map[string]bool{}
+	for _, option := range poll.Options {
+		option = strings.TrimSpace(option)
+		if option == "" || utf8.RuneCountInString(option) > maxPollOptionLength {
+			respondWithError(response, request, fmt.Sprintf("Poll options have to be 1 to %d characters long", maxPollOptionLength), nil, http.StatusBadRequest)
+			return nil, 0, false
+		}
+		if seen[strings.ToLower(option)] {
+			respondWithError(response, request, "Poll options have to be different from each other", nil, http.StatusBadRequest)
+			return nil, 0, false
+		}
+		seen[strings.ToLower(option)] = true
+		options = append(options, option)
+	}
+
+	duration := time.Duration(poll.DurationMinutes) * time.Minute
+	if duration < minPollDuration || duration > maxPollDuration {
+		respondWithError(response, request, fmt.Sprintf("duration_minutes has to be between %d and %d", int(minPollDuration.Minutes()), int(maxPollDuration.Minutes())), nil, http.StatusBadRequest)
+		return nil, 0, false
+	}
+	return options, duration, true
+}
+
+// createPoll adds a poll to a chirp that was just made, inside the same transaction
+func createPoll(ctx context.Context, queries *database.Queries, chirpID uuid.UUID, options []string, duration time.Duration) error {
+	if err := queries.CreatePoll(ctx, database.CreatePollParams{
+		ChirpID:         chirpID,
+		DurationSeconds: duration.Seconds(),
+	}); err != nil {
+		return err
+	}
+	return queries.AddPollOptions(ctx, database.AddPollOptionsParams{
+		ChirpID: chirpID,
+		Options: options,
+	})
+}
+
+// addPolls fills in the poll of every chirp that has one. viewerID decides whether the results can be shown
+func (config *apiConfig) addPolls(ctx context.Context, viewerID uuid.NullUUID, chirps []*Chirp) error {
+	chirpIDs := []uuid.UUID{}
+	for _, chirp := range chirps {
+		if !chirp.Deleted {
+			chirpIDs = append(chirpIDs, chirp.ID)
+		}
+	}
+
+	polls, err := config.dbQueries.GetPolls(ctx, chirpIDs)
+	if err != nil || len(polls) == 0 {
+		return err
+	}
+	pollIDs := []uuid.UUID{}
+	for _, poll := range polls {
+		pollIDs = append(pollIDs, poll.ChirpID)
+	}
+
+	results, err := config.dbQueries.GetPollResults(ctx, pollIDs)
+	if err != nil {
+		return err
+	}
+	votedFor := map[uuid.UUID]int32{}
+	if viewerID.Valid {
+		votes, err := config.dbQueries.GetPollVotesByUser(ctx, database.GetPollVotesByUserParams{
+			UserID:   viewerID.UUID,
+			ChirpIds: pollIDs,
+		})
+		if err != nil {
+			return err
+		}
+		for _, vote := range votes {
+			votedFor[vote.ChirpID] = vote.Position
+		}
+	}
+
+	resultsByPoll := map[uuid.UUID][]database.GetPollResultsRow{}
+	for _, result := range results {
+		resultsByPoll[result.ChirpID] = append(resultsByPoll[result.ChirpID], result)
+	}
+
+	chirpsByID := map[uuid.UUID][]*Chirp{}
+	for _, chirp := range chirps {
+		chirpsByID[chirp.ID] = append(chirpsByID[chirp.ID], chirp)
+	}
+
+	now := time.Now().UTC()
+	for _, sqlPoll := range polls {
+		for _, chirp := range chirpsByID[sqlPoll.ChirpID] {
+			poll := &Poll{
+				Options:  []PollOption{},
+				ClosesAt: sqlPoll.ClosesAt,
+				Closed:   !now.Before(sqlPoll.ClosesAt),
+			}
+			if position, ok := votedFor[sqlPoll.ChirpID]; ok {
+				poll.VotedFor = &position
+			}
+			showResults := poll.Closed || poll.VotedFor != nil || (viewerID.Valid && viewerID.UUID == chirp.UserID)
+
+			total := int64(0)
+			for _, result := range resultsByPoll[sqlPoll.ChirpID] {
+				option := PollOption{Position: result.Position, Text: result.Text}
+				if showResults {
+					option.Votes = &result.Votes
+				}
+				total += result.Votes
+				poll.Options = append(poll.Options, option)
+			}
+			if showResults {
+				poll.TotalVotes = &total
+			}
+			chirp.Poll = poll
+		}
+	}
+	return nil
+}
+
+// votePollHandler votes on the poll of {ChirpID}. Everyone gets one vote and it can't be changed
+func (config *apiConfig) votePollHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+	viewerID := uuid.NullUUID{UUID: userID, Valid: true}
+
+	chirpID, err := uuid.Parse(request.PathValue("ChirpID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing that UUID", err, http.StatusBadRequest)
+		return
+	}
+
+	type IncomingJSON struct {
+		Option *int32 `json:"option"`
+	}
+	incomingjson := IncomingJSON{}
+	if err = json.NewDecoder(request.Body).Decode(&incomingjson); err != nil || incomingjson.Option == nil {
+		respondWithError(response, request, "Something went wrong, required format {'option': POSITION}", err, http.StatusBadRequest)
+		return
+	}
+
+	sqlChirp, err := config.dbQueries.GetChirpForViewer(request.Context(), database.GetChirpForViewerParams{
+		ID:       chirpID,
+		ViewerID: viewerID,
+	})
+	if err != nil || sqlChirp.TombstonedAt.Valid {
+		respondWithError(response, request, "Chirp not found", err, http.StatusNotFound)
+		return
+	}
+	chirp := chirpFromDatabase(sqlChirp)
+	if err = config.addPolls(request.Context(), viewerID, []*Chirp{&chirp}); err != nil {
+		respondWithError(response, request, "There was an error loading the poll", err, http.StatusInternalServerError)
+		return
+	}
+
+	switch {
+	case chirp.Poll == nil:
+		respondWithError(response, request, "That chirp doesn't have a poll", nil, http.StatusNotFound)
+		return
+	case chirp.Poll.VotedFor != nil:
+		respondWithError(response, request, "You've already voted on this poll", nil, http.StatusConflict)
+		return
+	case chirp.Poll.Closed:
+		respondWithError(response, request, "This poll has closed", nil, http.StatusForbidden)
+		return
+	case *incomingjson.Option < 0 || int(*incomingjson.Option) >= len(chirp.Poll.Options):
+		respondWithError(response, request, "That option isn't in the poll", nil, http.StatusBadRequest)
+		return
+	}
+
+	rowsAffected, err := config.dbQueries.CastPollVote(request.Context(), database.CastPollVoteParams{
+		UserID:   userID,
+		Position: *incomingjson.Option,
+		ChirpID:  chirpID,
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error saving your vote", err, http.StatusInternalServerError)
+		return
+	}
+	// Nothing was added if the poll closed or another vote got in since it was checked
+	if rowsAffected == 0 {
+		respondWithError(response, request, "You've already voted on this poll, or it has closed", nil, http.StatusConflict)
+		return
+	}
+
+	if err = config.addPolls(request.Context(), viewerID, []*Chirp{&chirp}); err != nil {
+		respondWithError(response, request, "There was an error loading the poll", err, http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(response, request, chirp.Poll, http.StatusOK)
+}
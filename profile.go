@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/entities"
+)
+
+const (
+	// handleChangeCooldown stops people from cycling through handles, picking your first handle doesn't count
+	handleChangeCooldown = 7 * 24 * time.Hour
+
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxLocationLength    = 30
+	maxWebsiteLength     = 100
+)
+
+type Profile struct {
+	ID          uuid.UUID `json:"id"`
+	Handle      string    `json:"handle,omitempty"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	Location    string    `json:"location"`
+	Website     string    `json:"website"`
+	// Avatar and Banner map each size (the width in pixels) to its URL
+	Avatar         map[string]string `json:"avatar,omitempty"`
+	Banner         map[string]string `json:"banner,omitempty"`
+	IsChirpyRed    bool              `json:"is_chirpy_red"`
+	CreatedAt      time.Time         `json:"created_at"`
+	ChirpCount     int64             `json:"chirp_count"`
+	FollowerCount  int64             `json:"follower_count"`
+	FollowingCount int64             `json:"following_count"`
+}
+
+// ChirpAuthor is the small version of a profile that goes in every chirp
+type ChirpAuthor struct {
+	ID          uuid.UUID `json:"id"`
+	Handle      string    `json:"handle,omitempty"`
+	DisplayName string    `json:"display_name,omitempty"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+}
+
+func (config *apiConfig) getProfile(ctx context.Context, user database.User) (Profile, error) {
+	counts, err := config.dbQueries.GetProfileCounts(ctx, user.ID)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	return Profile{
+		ID:             user.ID,
+		Handle:         user.Handle.String,
+		DisplayName:    user.DisplayName,
+		Bio:            user.Bio,
+		Location:       user.Location,
+		Website:        user.Website,
+		Avatar:         config.imageURLs(avatarImage, user.ID, user.AvatarID),
+		Banner:         config.imageURLs(bannerImage, user.ID, user.BannerID),
+		IsChirpyRed:    user.IsChirpyRed,
+		CreatedAt:      user.CreatedAt,
+		ChirpCount:     counts.ChirpCount,
+		FollowerCount:  counts.FollowerCount,
+		FollowingCount: counts.FollowingCount,
+	}, nil
+}
+
+// addAuthors fills in the author of every chirp with a single query
+func (config *apiConfig) addAuthors(ctx context.Context, chirps []*Chirp) error {
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		ids = append(ids, chirp.UserID)
+	}
+
+	rows, err := config.dbQueries.GetChirpAuthors(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	authors := map[uuid.UUID]*ChirpAuthor{}
+	for _, row := range rows {
+		authors[row.ID] = &ChirpAuthor{
+			ID:          row.ID,
+			Handle:      row.Handle.String,
+			DisplayName: row.DisplayName,
+			IsChirpyRed: row.IsChirpyRed,
+		}
+		if row.AvatarID.Valid {
+			authors[row.ID].AvatarURL = config.blobs.URL(avatarImage.key(row.ID, row.AvatarID.UUID, 96))
+		}
+	}
+	for _, chirp := range chirps {
+		chirp.Author = authors[chirp.UserID]
+	}
+	return nil
+}
+
+// validateHandle writes the error response itself when the handle isn't allowed
+func validateHandle(response http.ResponseWriter, request *http.Request, handle string) bool {
+	if !entities.ValidHandle(handle) {
+		respondWithError(response, request, "A handle must be 1 to 15 letters, numbers or underscores", nil, http.StatusBadRequest)
+		return false
+	}
+	if entities.ReservedHandle(handle) {
+		respondWithError(response, request, "That handle is reserved", nil, http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// checkHandleChange writes the error response itself when the user can't switch to handle, because it isn't allowed or
+// they changed their handle too recently. Handles are unique whatever their case, so only changing the case of the
+// handle doesn't count as a new one. Whether it's taken is only found out when it's saved
+func checkHandleChange(response http.ResponseWriter, request *http.Request, user database.User, handle string) bool {
+	if user.Handle.Valid && user.Handle.String == handle {
+		return true
+	}
+	if !validateHandle(response, request, handle) {
+		return false
+	}
+	if user.Handle.Valid && user.HandleChangedAt.Valid && !strings.EqualFold(user.Handle.String, handle) {
+		if nextChange := user.HandleChangedAt.Time.Add(handleChangeCooldown); time.Now().UTC().Before(nextChange) {
+			response.Header().Set("Retry-After", fmt.Sprint(int(time.Until(nextChange).Seconds())+1))
+			respondWithError(response, request, "You can only change your handle once a week", nil, http.StatusTooManyRequests)
+			return false
+		}
+	}
+	return true
+}
+
+// setHandle changes the user's handle using queries, as long as checkHandleChange allows it. Picking a first handle
+// or changing the case of the current one doesn't start the cooldown, only replacing one does
+func (config *apiConfig) setHandle(response http.ResponseWriter, request *http.Request, queries *database.Queries, user database.User, handle string) (database.User, bool) {
+	if user.Handle.Valid && user.Handle.String == handle {
+		return user, true
+	}
+	if !checkHandleChange(response, request, user, handle) {
+		return database.User{}, false
+	}
+
+	user, err := queries.SetUserHandle(request.Context(), database.SetUserHandleParams{
+		ID:     user.ID,
+		Handle: sql.NullString{String: handle, Valid: true},
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(response, request, "That handle is already taken", err, http.StatusConflict)
+			return database.User{}, false
+		}
+		respondWithError(response, request, "There was an error when updating the handle...", err, http.StatusBadRequest)
+		return database.User{}, false
+	}
+	return user, true
+}
+
+// getProfileHandler shows anyone's public profile. {handle} can also be a user ID, or "me" with a JWT
+func (config *apiConfig) getProfileHandler(response http.ResponseWriter, request *http.Request) {
+	handle := request.PathValue("handle")
+
+	var user database.User
+	var err error
+	if handle == "me" {
+		var userID uuid.UUID
+		if _, userID, err = getJWTFromHeader(request.Header, config.secret); err != nil {
+			respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+			return
+		}
+		user, err = config.dbQueries.GetUserByID(request.Context(), userID)
+	} else if userID, parseErr := uuid.Parse(handle); parseErr == nil {
+		user, err = config.dbQueries.GetUserByID(request.Context(), userID)
+	} else {
+		user, err = config.dbQueries.GetUserByHandle(request.Context(), entities.NormaliseHandle(handle))
+	}
+	if err != nil {
+		respondWithError(response, request, "Couldn't find the user...", err, http.StatusNotFound)
+		return
+	}
+
+	profile, err := config.getProfile(request.Context(), user)
+	if err != nil {
+		respondWithError(response, request, "There was an error loading the profile", err, http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(response, request, profile, http.StatusOK)
+}
+
+// updateProfileHandler only changes the fields that are in the request, send "" to clear one
+func (config *apiConfig) updateProfileHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	user, err := config.dbQueries.GetUserByID(request.Context(), userID)
+	if err != nil {
+		respondWithError(response, request, "Couldn't find the user...", err, http.StatusNotFound)
+		return
+	}
+
+	type IncomingJSON struct {
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
+		Location    *string `json:"location"`
+		Website     *string `json:"website"`
+	}
+	incomingjson := IncomingJSON{}
+	if err = json.NewDecoder(request.Body).Decode(&incomingjson); err != nil {
+		respondWithError(response, request, "Something went wrong, required format: {'display_name', 'bio', 'location', 'website' (all optional)}", err, http.StatusBadRequest)
+		return
+	}
+
+	params := database.UpdateUserProfileParams{
+		ID:          userID,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		Location:    user.Location,
+		Website:     user.Website,
+	}
+	for _, field := range []struct {
+		name      string
+		value     *string
+		maxLength int
+		target    *string
+	}{
+		{"display_name", incomingjson.DisplayName, maxDisplayNameLength, &params.DisplayName},
+		{"bio", incomingjson.Bio, maxBioLength, &params.Bio},
+		{"location", incomingjson.Location, maxLocationLength, &params.Location},
+		{"website", incomingjson.Website, maxWebsiteLength, &params.Website},
+	} {
+		if field.value == nil {
+			continue
+		}
+		value := strings.TrimSpace(*field.value)
+		if utf8.RuneCountInString(value) > field.maxLength {
+			respondWithError(response, request, fmt.Sprintf("%s can't be longer than %d characters", field.name, field.maxLength), nil, http.StatusBadRequest)
+			return
+		}
+		*field.target = value
+	}
+
+	if params.Website != "" {
+		website, err := url.ParseRequestURI(params.Website)
+		if err != nil || (website.Scheme != "http" && website.Scheme != "https") || website.Host == "" {
+			respondWithError(response, request, "website must be an http or https link", err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	user, err = config.dbQueries.UpdateUserProfile(request.Context(), params)
+	if err != nil {
+		respondWithError(response, request, "There was an error updating your profile", err, http.StatusInternalServerError)
+		return
+	}
+
+	profile, err := config.getProfile(request.Context(), user)
+	if err != nil {
+		respondWithError(response, request, "There was an error loading the profile", err, http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(response, request, profile, http.StatusOK)
+}
+
+func (config *apiConfig) changeHandleHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	type IncomingJSON struct {
+		Handle string `json:"handle"`
+	}
+	incomingjson := IncomingJSON{}
+	if err = json.NewDecoder(request.Body).Decode(&incomingjson); err != nil {
+		respondWithError(response, request, "Something went wrong, required format: {'handle': 'HANDLE'}", err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := config.dbQueries.GetUserByID(request.Context(), userID)
+	if err != nil {
+		respondWithError(response, request, "Couldn't find the user...", err, http.StatusNotFound)
+		return
+	}
+
+	user, ok := config.setHandle(response, request, config.dbQueries, user, strings.TrimPrefix(incomingjson.Handle, "@"))
+	if !ok {
+		return
+	}
+
+	profile, err := config.getProfile(request.Context(), user)
+	if err != nil {
+		respondWithError(response, request, "There was an error loading the profile", err, http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(response, request, profile, http.StatusOK)
+}
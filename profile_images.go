@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/images"
+)
+
+const (
+	maxImageUploadSize = 5 << 20
+	// minProfileImageSide is the smallest width or height a profile picture can have, anything smaller would just be
+	// a blur once it's scaled up
+	minProfileImageSide = 32
+)
+
+// profileImage describes one kind of picture a user can upload, like their avatar
+type profileImage struct {
+	name string
+	// aspectWidth:aspectHeight is the shape the upload is cropped to before it's resized
+	aspectWidth  int
+	aspectHeight int
+	widths       []int
+	current      func(database.User) uuid.NullUUID
+	set          func(context.Context, *database.Queries, uuid.UUID, uuid.NullUUID) (database.User, error)
+}
+
+var avatarImage = profileImage{
+	name:         "avatar",
+	aspectWidth:  1,
+	aspectHeight: 1,
+	widths:       []int{48, 96, 400},
+	current:      func(user database.User) uuid.NullUUID { return user.AvatarID },
+	set: func(ctx context.Context, queries *database.Queries, userID uuid.UUID, imageID uuid.NullUUID) (database.User, error) {
+		return queries.SetUserAvatar(ctx, database.SetUserAvatarParams{ID: userID, AvatarID: imageID})
+	},
+}
+
+var bannerImage = profileImage{
+	name:         "banner",
+	aspectWidth:  3,
+	aspectHeight: 1,
+	widths:       []int{600, 1500},
+	current:      func(user database.User) uuid.NullUUID { return user.BannerID },
+	set: func(ctx context.Context, queries *database.Queries, userID uuid.UUID, imageID uuid.NullUUID) (database.User, error) {
+		return queries.SetUserBanner(ctx, database.SetUserBannerParams{ID: userID, BannerID: imageID})
+	},
+}
+
+func (kind profileImage) key(userID, imageID uuid.UUID, width int) string {
+	return fmt.Sprintf("%ss/%s/%s_%d.jpg", kind.name, userID, imageID, width)
+}
+
+// imageURLs maps each width to where it can be downloaded, nil when there's no image
+func (config *apiConfig) imageURLs(kind profileImage, userID uuid.UUID, imageID uuid.NullUUID) map[string]string {
+	if !imageID.Valid {
+		return nil
+	}
+	urls := map[string]string{}
+	for _, width := range kind.widths {
+		urls[strconv.Itoa(width)] = config.blobs.URL(kind.key(userID, imageID.UUID, width))
+	}
+	return urls
+}
+
+// deleteImageBlobs is best effort, a leftover file is only wasted space
+func (config *apiConfig) deleteImageBlobs(ctx context.Context, kind profileImage, userID, imageID uuid.UUID) {
+	for _, width := range kind.widths {
+		if err := config.blobs.Delete(ctx, kind.key(userID, imageID, width)); err != nil {
+			log.Printf("Error deleting an old %s: %s\n", kind.name, err)
+		}
+	}
+}
+
+// readImageUpload reads and decodes the multipart "image" field, writing the error response itself if anything is wrong.
+// It also returns the raw bytes and the format ("jpeg", "png" or "gif")
+func readImageUpload(response http.ResponseWriter, request *http.Request) ([]byte, image.Image, string, bool) {
+	// Leave some room for the rest of the multipart body
+	request.Body = http.MaxBytesReader(response, request.Body, maxImageUploadSize+1<<20)
+	file, header, err := request.FormFile("image")
+	if err != nil {
+		if maxBytesErr := (&http.MaxBytesError{}); errors.As(err, &maxBytesErr) {
+			respondWithError(response, request, "Images can't be bigger than 5MB", err, http.StatusRequestEntityTooLarge)
+			return nil, nil, "", false
+		}
+		respondWithError(response, request, "Something went wrong, send the image as multipart/form-data in the 'image' field", err, http.StatusBadRequest)
+		return nil, nil, "", false
+	}
+	defer file.Close()
+
+	if contentType := header.Header.Get("Content-Type"); contentType != "image/jpeg" && contentType != "image/png" && contentType != "image/gif" {
+		respondWithError(response, request, images.ErrUnsupportedType.Error(), nil, http.StatusUnsupportedMediaType)
+		return nil, nil, "", false
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxImageUploadSize+1))
+	if err != nil {
+		respondWithError(response, request, "There was an error reading the image", err, http.StatusBadRequest)
+		return nil, nil, "", false
+	}
+	if len(data) > maxImageUploadSize {
+		respondWithError(response, request, "Images can't be bigger than 5MB", nil, http.StatusRequestEntityTooLarge)
+		return nil, nil, "", false
+	}
+
+	img, format, err := images.Decode(data)
+	if err != nil {
+		switch {
+		case errors.Is(err, images.ErrUnsupportedType):
+			respondWithError(response, request, err.Error(), err, http.StatusUnsupportedMediaType)
+		case errors.Is(err, images.ErrTooLarge):
+			respondWithError(response, request, fmt.Sprintf("Images can't be more than %d pixels wide or tall", images.MaxDimension), err, http.StatusRequestEntityTooLarge)
+		default:
+			respondWithError(response, request, "Couldn't read that image", err, http.StatusBadRequest)
+		}
+		return nil, nil, "", false
+	}
+	return data, img, format, true
+}
+
+// uploadImageHandler takes a multipart upload in the "image" field. The image is decoded and encoded again as a JPEG
+// for each size, so EXIF data (like where the photo was taken) never gets stored
+func (config *apiConfig) uploadImageHandler(kind profileImage) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		_, userID, err := getJWTFromHeader(request.Header, config.secret)
+		if err != nil {
+			respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+			return
+		}
+
+		_, img, _, ok := readImageUpload(response, request)
+		if !ok {
+			return
+		}
+		if img.Bounds().Dx() < minProfileImageSide || img.Bounds().Dy() < minProfileImageSide {
+			respondWithError(response, request, fmt.Sprintf("Images have to be at least %dx%d pixels", minProfileImageSide, minProfileImageSide), nil, http.StatusBadRequest)
+			return
+		}
+
+		oldUser, err := config.dbQueries.GetUserByID(request.Context(), userID)
+		if err != nil {
+			respondWithError(response, request, "Couldn't find the user...", err, http.StatusNotFound)
+			return
+		}
+
+		imageID := uuid.New()
+		// Every width is resized from the same copy, rather than copying the whole upload again for each one
+		cropped := images.Flatten(images.CropToAspect(img, kind.aspectWidth, kind.aspectHeight))
+		for _, width := range kind.widths {
+			buffer := bytes.Buffer{}
+			if err = images.EncodeJPEG(&buffer, images.Resize(cropped, width, width*kind.aspectHeight/kind.aspectWidth)); err == nil {
+				err = config.blobs.Put(request.Context(), kind.key(userID, imageID, width), &buffer)
+			}
+			if err != nil {
+				config.deleteImageBlobs(request.Context(), kind, userID, imageID)
+				respondWithError(response, request, "There was an error saving the image", err, http.StatusInternalServerError)
+				return
+			}
+		}
+
+		user, err := kind.set(request.Context(), config.dbQueries, userID, uuid.NullUUID{UUID: imageID, Valid: true})
+		if err != nil {
+			config.deleteImageBlobs(request.Context(), kind, userID, imageID)
+			respondWithError(response, request, "There was an error saving the image", err, http.StatusInternalServerError)
+			return
+		}
+		if old := kind.current(oldUser); old.Valid {
+			config.deleteImageBlobs(request.Context(), kind, userID, old.UUID)
+		}
+
+		profile, err := config.getProfile(request.Context(), user)
+		if err != nil {
+			respondWithError(response, request, "There was an error loading the profile", err, http.StatusInternalServerError)
+			return
+		}
+		respondWithJSON(response, request, profile, http.StatusOK)
+	}
+}
+
+func (config *apiConfig) deleteImageHandler(kind profileImage) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		_, userID, err := getJWTFromHeader(request.Header, config.secret)
+		if err != nil {
+			respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+			return
+		}
+
+		user, err := config.dbQueries.GetUserByID(request.Context(), userID)
+		if err != nil {
+			respondWithError(response, request, "Couldn't find the user...", err, http.StatusNotFound)
+			return
+		}
+		old := kind.current(user)
+		if !old.Valid {
+			respondWithError(response, request, "You don't have a "+kind.name, nil, http.StatusNotFound)
+			return
+		}
+
+		if _, err = kind.set(request.Context(), config.dbQueries, userID, uuid.NullUUID{}); err != nil {
+			respondWithError(response, request, "There was an error removing the "+kind.name, err, http.StatusInternalServerError)
+			return
+		}
+		config.deleteImageBlobs(request.Context(), kind, userID, old.UUID)
+		response.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// middlewareCacheForever is for files whose URL changes whenever their content does
+func middlewareCacheForever(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		// No directory listings
+		if request.URL.Path == "" || request.URL.Path[len(request.URL.Path)-1] == '/' {
+			http.NotFound(response, request)
+			return
+		}
+		response.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		response.Header().Set("X-Content-Type-Options", "nosniff")
+		next.ServeHTTP(response, request)
+	})
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/pubsub"
+)
+
+func (config *apiConfig) rechirpHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	original, ok := config.getRechirpTarget(response, request, userID)
+	if !ok {
+		return
+	}
+	if original.TombstonedAt.Valid {
+		respondWithError(response, request, "You can't rechirp a deleted chirp", nil, http.StatusBadRequest)
+		return
+	}
+
+	tx, err := config.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(response, request, "There was an error rechirping the chirp", err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	queries := config.dbQueries.WithTx(tx)
+
+	sqlRechirp, err := queries.CreateRechirp(request.Context(), database.CreateRechirpParams{
+		UserID:      userID,
+		RechirpOfID: uuid.NullUUID{UUID: original.ID, Valid: true},
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(response, request, "You have already rechirped that chirp", err, http.StatusConflict)
+			return
+		}
+		respondWithError(response, request, "There was an error rechirping the chirp", err, http.StatusBadRequest)
+		return
+	}
+
+	if err = queries.FanOutChirp(request.Context(), sqlRechirp.ID); err != nil {
+		respondWithError(response, request, "There was an error adding the rechirp to timelines", err, http.StatusInternalServerError)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		respondWithError(response, request, "There was an error rechirping the chirp", err, http.StatusInternalServerError)
+		return
+	}
+
+	rechirp := chirpFromDatabase(sqlRechirp)
+	if err = config.hydrateChirps(request.Context(), uuid.NullUUID{UUID: userID, Valid: true}, []*Chirp{&rechirp}); err != nil {
+		respondWithError(response, request, "There was an error loading the rechirp", err, http.StatusInternalServerError)
+		return
+	}
+	if err = config.publishChirp(request.Context(), pubsub.EventChirpCreated, sqlRechirp); err != nil {
+		log.Printf("Error publishing the rechirp %s: %s\n", rechirp.ID, err)
+	}
+	respondWithJSON(response, request, rechirp, http.StatusCreated)
+}
+
+func (config *apiConfig) undoRechirpHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	original, ok := config.getRechirpTarget(response, request, userID)
+	if !ok {
+		return
+	}
+
+	rechirp, err := config.dbQueries.DeleteRechirp(request.Context(), database.DeleteRechirpParams{
+		UserID:      userID,
+		RechirpOfID: uuid.NullUUID{UUID: original.ID, Valid: true},
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(response, request, "You haven't rechirped that chirp", err, http.StatusNotFound)
+			return
+		}
+		respondWithError(response, request, "There was an error undoing the rechirp", err, http.StatusBadRequest)
+		return
+	}
+
+	if err = config.publishChirpDeleted(request.Context(), rechirp); err != nil {
+		log.Printf("Error publishing the deleted rechirp %s: %s\n", rechirp.ID, err)
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
+
+// getRechirpTarget finds the chirp in the URL. When it is a rechirp itself, the original chirp is returned instead.
+// Chirps from users on either side of a block with the user aren't found
+func (config *apiConfig) getRechirpTarget(response http.ResponseWriter, request *http.Request, userID uuid.UUID) (database.Chirp, bool) {
+	chirpID, err := uuid.Parse(request.PathValue("ChirpID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing that UUID", err, http.StatusBadRequest)
+		return database.Chirp{}, false
+	}
+
+	viewerID := uuid.NullUUID{UUID: userID, Valid: true}
+	chirp, err := config.dbQueries.GetChirpForViewer(request.Context(), database.GetChirpForViewerParams{ID: chirpID, ViewerID: viewerID})
+	if err != nil {
+		respondWithError(response, request, "Chirp not found", err, http.StatusNotFound)
+		return database.Chirp{}, false
+	}
+	if !chirp.RechirpOfID.Valid {
+		return chirp, true
+	}
+
+	original, err := config.dbQueries.GetChirpForViewer(request.Context(), database.GetChirpForViewerParams{ID: chirp.RechirpOfID.UUID, ViewerID: viewerID})
+	if err != nil {
+		respondWithError(response, request, "Chirp not found", err, http.StatusNotFound)
+		return database.Chirp{}, false
+	}
+	return original, true
+}
+
+// addEmbeddedChirps loads the quoted_chirp and rechirp_of objects with a single query. Chirps from users on either side
+// of a block with the viewer, or that they've muted, are left out. Embedded chirps don't get their own embeds, the
+// returned pointers are so the caller can fill in the rest of their fields
+func (config *apiConfig) addEmbeddedChirps(ctx context.Context, viewerID uuid.NullUUID, chirps []*Chirp) ([]*Chirp, error) {
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		if chirp.quotedChirpID.Valid {
+			ids = append(ids, chirp.quotedChirpID.UUID)
+		}
+		if chirp.rechirpOfID.Valid {
+			ids = append(ids, chirp.rechirpOfID.UUID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	sqlChirps, err := config.dbQueries.GetChirpsByIDs(ctx, database.GetChirpsByIDsParams{Ids: ids, ViewerID: viewerID})
+	if err != nil {
+		return nil, err
+	}
+	byID := map[uuid.UUID]database.Chirp{}
+	for _, sqlChirp := range sqlChirps {
+		byID[sqlChirp.ID] = sqlChirp
+	}
+
+	embedded := []*Chirp{}
+	embed := func(id uuid.NullUUID) *Chirp {
+		sqlChirp, ok := byID[id.UUID]
+		if !id.Valid || !ok {
+			return nil
+		}
+		chirp := chirpFromDatabase(sqlChirp)
+		embedded = append(embedded, &chirp)
+		return &chirp
+	}
+	for _, chirp := range chirps {
+		chirp.QuotedChirp = embed(chirp.quotedChirpID)
+		chirp.RechirpOf = embed(chirp.rechirpOfID)
+	}
+	return embedded, nil
+}
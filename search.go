@@ -0,0 +1,110 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/pagination"
+	"github.com/vilebile17/chirpy/internal/search"
+)
+
+// SearchResult is a chirp that matched. Snippet is the body with the matching words wrapped in <mark> tags, the rest of
+// it is HTML escaped so that it is safe to show as HTML
+type SearchResult struct {
+	Chirp
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// searchChirpsHandler runs a full text search over the chirp bodies, the best matches come first.
+// Supports ?q= (required), ?author_id=, ?since=, ?until= (RFC3339 timestamps), ?limit= and ?offset=
+func (config *apiConfig) searchChirpsHandler(response http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+
+	tsQuery, err := search.BuildTSQuery(query.Get("q"))
+	if err != nil {
+		respondWithError(response, request, "Something went wrong, required format: /api/chirps/search?q=QUERY", err, http.StatusBadRequest)
+		return
+	}
+
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(response, request, err.Error(), err, http.StatusBadRequest)
+		return
+	}
+	offset, err := pagination.ParseOffset(query.Get("offset"))
+	if err != nil {
+		respondWithError(response, request, err.Error(), err, http.StatusBadRequest)
+		return
+	}
+
+	viewerID := getViewerFromHeader(request.Header, config.secret)
+	params := database.SearchChirpsParams{
+		Query:     tsQuery,
+		ViewerID:  viewerID,
+		RowLimit:  int32(limit + 1),
+		RowOffset: int32(offset),
+	}
+	if authorID := query.Get("author_id"); authorID != "" {
+		userID, err := uuid.Parse(authorID)
+		if err != nil {
+			respondWithError(response, request, "There was an error parsing the UUID of the user", err, http.StatusBadRequest)
+			return
+		}
+		params.AuthorID = uuid.NullUUID{UUID: userID, Valid: true}
+	}
+	if params.Since, err = parseTimeParam(query.Get("since")); err != nil {
+		respondWithError(response, request, "since must be an RFC3339 timestamp", err, http.StatusBadRequest)
+		return
+	}
+	if params.Until, err = parseTimeParam(query.Get("until")); err != nil {
+		respondWithError(response, request, "until must be an RFC3339 timestamp", err, http.StatusBadRequest)
+		return
+	}
+
+	rows, err := config.dbQueries.SearchChirps(request.Context(), params)
+	if err != nil {
+		respondWithError(response, request, "There was an error searching the Chirps", err, http.StatusBadRequest)
+		return
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		response.Header().Set("Link", pagination.NextLink(request.URL, "offset", strconv.Itoa(offset+limit)))
+	}
+
+	results := []SearchResult{}
+	for _, row := range rows {
+		results = append(results, SearchResult{
+			Chirp:   chirpFromDatabase(row.Chirp),
+			Rank:    row.Rank,
+			Snippet: row.Snippet,
+		})
+	}
+
+	chirps := []*Chirp{}
+	for i := range results {
+		chirps = append(chirps, &results[i].Chirp)
+	}
+	if err = config.hydrateChirps(request.Context(), viewerID, chirps); err != nil {
+		respondWithError(response, request, "There was an error loading the Chirps", err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(response, request, results, http.StatusOK)
+}
+
+func parseTimeParam(value string) (sql.NullTime, error) {
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+}
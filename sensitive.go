@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/pubsub"
+)
+
+const maxContentWarningLength = 100
+
+// validateContentWarning writes the error response itself when the content warning is too long
+func validateContentWarning(response http.ResponseWriter, request *http.Request, contentWarning string) bool {
+	if utf8.RuneCountInString(contentWarning) > maxContentWarningLength {
+		respondWithError(response, request, fmt.Sprintf("Content warnings can't be longer than %d characters", maxContentWarningLength), nil, http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// addCollapsed sets collapsed on the chirps with a content warning or that are sensitive, unless the viewer has chosen
+// to have them expanded. Logged out viewers always get them collapsed
+func (config *apiConfig) addCollapsed(ctx context.Context, viewerID uuid.NullUUID, chirps []*Chirp) error {
+	collapsible := []*Chirp{}
+	for _, chirp := range chirps {
+		if !chirp.Deleted && (chirp.ContentWarning != "" || chirp.Sensitive) {
+			collapsible = append(collapsible, chirp)
+		}
+	}
+	if len(collapsible) == 0 {
+		return nil
+	}
+
+	if viewerID.Valid {
+		viewer, err := config.dbQueries.GetUserByID(ctx, viewerID.UUID)
+		if err != nil {
+			return err
+		}
+		if viewer.ExpandSensitive {
+			return nil
+		}
+	}
+	for _, chirp := range collapsible {
+		chirp.Collapsed = true
+	}
+	return nil
+}
+
+// setSensitiveHandler marks {ChirpID} as sensitive or not. Authors can change it on their own chirps, and moderators
+// can force it onto anyone's. A chirp a moderator has marked can't be unmarked by its author
+func (config *apiConfig) setSensitiveHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(request.PathValue("ChirpID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing that UUID", err, http.StatusBadRequest)
+		return
+	}
+
+	type IncomingJSON struct {
+		Sensitive *bool `json:"sensitive"`
+	}
+	incomingjson := IncomingJSON{}
+	if err = json.NewDecoder(request.Body).Decode(&incomingjson); err != nil || incomingjson.Sensitive == nil {
+		respondWithError(response, request, "Something went wrong, required format {'sensitive': BOOL}", err, http.StatusBadRequest)
+		return
+	}
+	sensitive := *incomingjson.Sensitive
+
+	chirp, err := config.dbQueries.GetOneChirp(request.Context(), chirpID)
+	if err != nil {
+		respondWithError(response, request, "Chirp not found", err, http.StatusNotFound)
+		return
+	}
+	if chirp.TombstonedAt.Valid {
+		respondWithError(response, request, "You can't change a deleted chirp", nil, http.StatusBadRequest)
+		return
+	}
+	if chirp.RechirpOfID.Valid {
+		respondWithError(response, request, "Rechirps can't be marked as sensitive, mark the original chirp instead", nil, http.StatusBadRequest)
+		return
+	}
+
+	user, err := config.dbQueries.GetUserByID(request.Context(), userID)
+	if err != nil {
+		respondWithError(response, request, "Couldn't find the user...", err, http.StatusNotFound)
+		return
+	}
+
+	forced := chirp.SensitiveForced
+	switch {
+	case user.IsModerator && chirp.UserID != userID:
+		forced = sensitive
+	case chirp.UserID != userID:
+		respondWithError(response, request, "Only the author or a moderator can change whether this chirp is sensitive", nil, http.StatusForbidden)
+		return
+	case forced && !sensitive:
+		respondWithError(response, request, "A moderator has marked this chirp as sensitive", nil, http.StatusForbidden)
+		return
+	}
+
+	chirp, err = config.dbQueries.SetChirpSensitive(request.Context(), database.SetChirpSensitiveParams{
+		ID:              chirpID,
+		Sensitive:       sensitive,
+		SensitiveForced: forced,
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error updating the chirp", err, http.StatusInternalServerError)
+		return
+	}
+
+	result := chirpFromDatabase(chirp)
+	if err = config.hydrateChirps(request.Context(), uuid.NullUUID{UUID: userID, Valid: true}, []*Chirp{&result}); err != nil {
+		respondWithError(response, request, "There was an error loading the chirp", err, http.StatusInternalServerError)
+		return
+	}
+
+	if err = config.publishChirp(request.Context(), pubsub.EventChirpUpdated, chirp); err != nil {
+		log.Printf("Error publishing the updated chirp %s: %s\n", result.ID, err)
+	}
+	respondWithJSON(response, request, result, http.StatusOK)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vilebile17/chirpy/internal/database"
+)
+
+type Settings struct {
+	AllowDMsFromStrangers bool `json:"allow_dms_from_strangers"`
+	// ExpandSensitive shows chirps with a content warning or that are sensitive without having to click through them
+	ExpandSensitive bool `json:"expand_sensitive"`
+}
+
+func settingsFromDatabase(user database.User) Settings {
+	return Settings{
+		AllowDMsFromStrangers: user.AllowDmsFromStrangers,
+		ExpandSensitive:       user.ExpandSensitive,
+	}
+}
+
+func (config *apiConfig) getSettingsHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	user, err := config.dbQueries.GetUserByID(request.Context(), userID)
+	if err != nil {
+		respondWithError(response, request, "Couldn't find the user...", err, http.StatusNotFound)
+		return
+	}
+	respondWithJSON(response, request, settingsFromDatabase(user), http.StatusOK)
+}
+
+// updateSettingsHandler only changes the settings that are in the request, the rest stay as they are
+func (config *apiConfig) updateSettingsHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	user, err := config.dbQueries.GetUserByID(request.Context(), userID)
+	if err != nil {
+		respondWithError(response, request, "Couldn't find the user...", err, http.StatusNotFound)
+		return
+	}
+
+	type IncomingJSON struct {
+		AllowDMsFromStrangers *bool `json:"allow_dms_from_strangers"`
+		ExpandSensitive       *bool `json:"expand_sensitive"`
+	}
+	incomingjson := IncomingJSON{}
+	if err = json.NewDecoder(request.Body).Decode(&incomingjson); err != nil {
+		respondWithError(response, request, "Something went wrong, required format: {'allow_dms_from_strangers': BOOL, 'expand_sensitive': BOOL}", err, http.StatusBadRequest)
+		return
+	}
+
+	if incomingjson.AllowDMsFromStrangers != nil || incomingjson.ExpandSensitive != nil {
+		params := database.UpdateUserSettingsParams{
+			ID:                    userID,
+			AllowDmsFromStrangers: user.AllowDmsFromStrangers,
+			ExpandSensitive:       user.ExpandSensitive,
+		}
+		if incomingjson.AllowDMsFromStrangers != nil {
+			params.AllowDmsFromStrangers = *incomingjson.AllowDMsFromStrangers
+		}
+		if incomingjson.ExpandSensitive != nil {
+			params.ExpandSensitive = *incomingjson.ExpandSensitive
+		}
+		user, err = config.dbQueries.UpdateUserSettings(request.Context(), params)
+		if err != nil {
+			respondWithError(response, request, "There was an error updating your settings", err, http.StatusInternalServerError)
+			return
+		}
+	}
+	respondWithJSON(response, request, settingsFromDatabase(user), http.StatusOK)
+}
@@ -0,0 +1,56 @@
+-- name: BlockUser :execrows
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES (
+  $1,
+  $2,
+  NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :execrows
+DELETE FROM blocks
+WHERE blocker_id = $1
+  AND blocked_id = $2;
+
+-- name: MuteUser :execrows
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES (
+  $1,
+  $2,
+  NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnmuteUser :execrows
+DELETE FROM mutes
+WHERE muter_id = $1
+  AND muted_id = $2;
+
+-- name: ListBlockedUsers :many
+SELECT blocks.blocked_id, users.handle, blocks.created_at FROM blocks
+JOIN users ON users.id = blocks.blocked_id
+WHERE blocks.blocker_id = $1
+ORDER BY blocks.created_at DESC, blocks.blocked_id;
+
+-- name: ListMutedUsers :many
+SELECT mutes.muted_id, users.handle, mutes.created_at FROM mutes
+JOIN users ON users.id = mutes.muted_id
+WHERE mutes.muter_id = $1
+ORDER BY mutes.created_at DESC, mutes.muted_id;
+
+-- name: IsBlockedEitherWay :one
+SELECT EXISTS (
+  SELECT 1 FROM blocks
+  WHERE (blocker_id = $1 AND blocked_id = $2)
+    OR (blocker_id = $2 AND blocked_id = $1)
+);
+
+-- name: ListHiddenUsers :many
+SELECT blocked_id AS user_id, FALSE AS muted FROM blocks
+WHERE blocker_id = sqlc.arg('viewer_id')
+UNION
+SELECT blocker_id, FALSE FROM blocks
+WHERE blocked_id = sqlc.arg('viewer_id')
+UNION
+SELECT muted_id, TRUE FROM mutes
+WHERE muter_id = sqlc.arg('viewer_id');
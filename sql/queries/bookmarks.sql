@@ -0,0 +1,124 @@
+-- name: AddBookmark :execrows
+INSERT INTO bookmarks (user_id, chirp_id, created_at)
+VALUES (
+  $1,
+  $2,
+  NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveBookmark :execrows
+DELETE FROM bookmarks
+WHERE user_id = $1
+  AND chirp_id = $2;
+
+-- name: GetBookmarkedChirps :many
+SELECT sqlc.embed(chirps), bookmarks.created_at AS saved_at FROM bookmarks
+JOIN chirps ON chirps.id = bookmarks.chirp_id
+WHERE bookmarks.user_id = sqlc.arg('user_id')
+  AND chirps.tombstoned_at IS NULL
+  AND chirps.deleted_at IS NULL
+  AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW())
+  AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = sqlc.arg('user_id') AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.arg('user_id'))
+  )
+  AND (sqlc.narg('before_created_at')::timestamp IS NULL
+    OR (bookmarks.created_at, bookmarks.chirp_id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_id')::uuid))
+ORDER BY bookmarks.created_at DESC, bookmarks.chirp_id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: GetBookmarkedChirpIDs :many
+SELECT chirp_id FROM bookmarks
+WHERE user_id = sqlc.arg('user_id')
+  AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: RemoveChirpFromBookmarks :exec
+DELETE FROM bookmarks
+WHERE chirp_id = $1;
+
+-- name: CreateCollection :one
+INSERT INTO collections (id, user_id, name, created_at, updated_at)
+VALUES (
+  gen_random_uuid(),
+  $1,
+  $2,
+  NOW(),
+  NOW()
+)
+RETURNING *;
+
+-- name: CountCollections :one
+SELECT COUNT(*) FROM collections
+WHERE user_id = $1;
+
+-- name: ListCollections :many
+SELECT sqlc.embed(collections), COUNT(chirps.id) AS chirp_count FROM collections
+LEFT JOIN collection_chirps ON collection_chirps.collection_id = collections.id
+LEFT JOIN chirps ON chirps.id = collection_chirps.chirp_id
+  AND chirps.tombstoned_at IS NULL
+  AND chirps.deleted_at IS NULL
+  AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW())
+  AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = collections.user_id AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = collections.user_id)
+  )
+WHERE collections.user_id = $1
+GROUP BY collections.id
+ORDER BY collections.created_at, collections.id;
+
+-- name: GetCollection :one
+SELECT * FROM collections
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: RenameCollection :one
+UPDATE collections
+SET
+  name = $3,
+  updated_at = NOW()
+WHERE id = $1
+  AND user_id = $2
+RETURNING *;
+
+-- name: DeleteCollection :execrows
+DELETE FROM collections
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: AddChirpToCollection :execrows
+INSERT INTO collection_chirps (collection_id, chirp_id, created_at)
+VALUES (
+  $1,
+  $2,
+  NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveChirpFromCollection :execrows
+DELETE FROM collection_chirps
+WHERE collection_id = $1
+  AND chirp_id = $2;
+
+-- name: GetCollectionChirps :many
+SELECT sqlc.embed(chirps), collection_chirps.created_at AS saved_at FROM collection_chirps
+JOIN chirps ON chirps.id = collection_chirps.chirp_id
+WHERE collection_chirps.collection_id = sqlc.arg('collection_id')
+  AND chirps.tombstoned_at IS NULL
+  AND chirps.deleted_at IS NULL
+  AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW())
+  AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = sqlc.arg('user_id') AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.arg('user_id'))
+  )
+  AND (sqlc.narg('before_created_at')::timestamp IS NULL
+    OR (collection_chirps.created_at, collection_chirps.chirp_id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_id')::uuid))
+ORDER BY collection_chirps.created_at DESC, collection_chirps.chirp_id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: RemoveChirpFromCollections :exec
+DELETE FROM collection_chirps
+WHERE chirp_id = $1;
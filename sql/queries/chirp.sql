@@ -1,21 +1,42 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to_id, quoted_chirp_id, expires_at, content_warning, sensitive)
 VALUES (
   gen_random_uuid(),
   NOW(),
   NOW(),
   $1,
-  $2
+  $2,
+  $3,
+  $4,
+  $5,
+  $6,
+  $7
 )
 RETURNING *;
 
 -- name: GetAllChirps :many
 SELECT * FROM chirps
+WHERE tombstoned_at IS NULL
+  AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW())
 ORDER BY created_at ASC;
 
 -- name: GetOneChirp :one
 SELECT * FROM chirps
-WHERE id = $1;
+WHERE id = $1
+  AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW());
+
+-- name: GetChirpForViewer :one
+SELECT * FROM chirps
+WHERE id = sqlc.arg('id')
+  AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND (sqlc.narg('viewer_id')::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = sqlc.narg('viewer_id')::uuid AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id')::uuid)
+  ));
 
 -- name: DeleteChirp :exec
 DELETE FROM chirps
@@ -23,4 +44,277 @@ WHERE id = $1;
 
 -- name: SearchChirpByAuthor :many
 SELECT * FROM chirps
-WHERE user_id = $1;
+WHERE user_id = $1
+  AND tombstoned_at IS NULL
+  AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW());
+
+-- name: ListChirpsAscending :many
+SELECT * FROM chirps
+WHERE tombstoned_at IS NULL
+  AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id')::uuid)
+  AND (sqlc.narg('after_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+  AND (sqlc.narg('before_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_id')::uuid))
+  AND (sqlc.narg('viewer_id')::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = sqlc.narg('viewer_id')::uuid AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id')::uuid)
+  ))
+  AND (sqlc.narg('viewer_id')::uuid IS NULL OR sqlc.narg('author_id')::uuid IS NOT NULL OR NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = sqlc.narg('viewer_id')::uuid
+      AND mutes.muted_id = chirps.user_id
+  ))
+  AND (sqlc.narg('viewer_id')::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM chirps AS originals
+    WHERE originals.id = chirps.rechirp_of_id
+      AND (EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = sqlc.narg('viewer_id')::uuid AND blocks.blocked_id = originals.user_id)
+          OR (blocks.blocker_id = originals.user_id AND blocks.blocked_id = sqlc.narg('viewer_id')::uuid)
+      ) OR EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.narg('viewer_id')::uuid
+          AND mutes.muted_id = originals.user_id
+      ))
+  ))
+  AND (NOT sqlc.arg('hide_sensitive')::boolean OR NOT (chirps.sensitive OR EXISTS (
+    SELECT 1 FROM chirps AS originals
+    WHERE originals.id = chirps.rechirp_of_id
+      AND originals.sensitive
+  )))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('row_limit');
+
+-- name: ListChirpsDescending :many
+SELECT * FROM chirps
+WHERE tombstoned_at IS NULL
+  AND deleted_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id')::uuid)
+  AND (sqlc.narg('after_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::uuid))
+  AND (sqlc.narg('before_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_id')::uuid))
+  AND (sqlc.narg('viewer_id')::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = sqlc.narg('viewer_id')::uuid AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id')::uuid)
+  ))
+  AND (sqlc.narg('viewer_id')::uuid IS NULL OR sqlc.narg('author_id')::uuid IS NOT NULL OR NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = sqlc.narg('viewer_id')::uuid
+      AND mutes.muted_id = chirps.user_id
+  ))
+  AND (sqlc.narg('viewer_id')::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM chirps AS originals
+    WHERE originals.id = chirps.rechirp_of_id
+      AND (EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = sqlc.narg('viewer_id')::uuid AND blocks.blocked_id = originals.user_id)
+          OR (blocks.blocker_id = originals.user_id AND blocks.blocked_id = sqlc.narg('viewer_id')::uuid)
+      ) OR EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.narg('viewer_id')::uuid
+          AND mutes.muted_id = originals.user_id
+      ))
+  ))
+  AND (NOT sqlc.arg('hide_sensitive')::boolean OR NOT (chirps.sensitive OR EXISTS (
+    SELECT 1 FROM chirps AS originals
+    WHERE originals.id = chirps.rechirp_of_id
+      AND originals.sensitive
+  )))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: SearchChirps :many
+SELECT
+  sqlc.embed(chirps),
+  ts_rank(chirps.search_vector, to_tsquery('english', sqlc.arg('query'))) AS rank,
+  ts_headline(
+    'english',
+    replace(replace(replace(replace(replace(chirps.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;'),
+    to_tsquery('english', sqlc.arg('query')),
+    'StartSel=<mark>, StopSel=</mark>, HighlightAll=TRUE'
+  ) AS snippet
+FROM chirps
+WHERE chirps.search_vector @@ to_tsquery('english', sqlc.arg('query'))
+  AND chirps.deleted_at IS NULL
+  AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW())
+  AND (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id')::uuid)
+  AND (sqlc.narg('since')::timestamp IS NULL OR chirps.created_at >= sqlc.narg('since')::timestamp)
+  AND (sqlc.narg('until')::timestamp IS NULL OR chirps.created_at < sqlc.narg('until')::timestamp)
+  AND (sqlc.narg('viewer_id')::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = sqlc.narg('viewer_id')::uuid AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id')::uuid)
+  ))
+  AND (sqlc.narg('viewer_id')::uuid IS NULL OR sqlc.narg('author_id')::uuid IS NOT NULL OR NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = sqlc.narg('viewer_id')::uuid
+      AND mutes.muted_id = chirps.user_id
+  ))
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('row_limit')
+OFFSET sqlc.arg('row_offset');
+
+-- name: ChirpHasDependents :one
+SELECT EXISTS (
+  SELECT 1 FROM chirps
+  WHERE in_reply_to_id = sqlc.arg('chirp_id')::uuid
+    OR quoted_chirp_id = sqlc.arg('chirp_id')::uuid
+);
+
+-- name: TombstoneChirp :exec
+UPDATE chirps
+SET
+  body = '',
+  tombstoned_at = NOW(),
+  deleted_at = NULL,
+  expires_at = NULL,
+  updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors (id, in_reply_to_id, depth) AS (
+  SELECT chirps.id, chirps.in_reply_to_id, 0
+  FROM chirps
+  WHERE chirps.id = sqlc.arg('id')
+  UNION ALL
+  SELECT chirps.id, chirps.in_reply_to_id, ancestors.depth + 1
+  FROM chirps
+  JOIN ancestors ON chirps.id = ancestors.in_reply_to_id
+)
+SELECT chirps.* FROM chirps
+JOIN ancestors ON chirps.id = ancestors.id
+WHERE ancestors.depth > 0
+  AND (sqlc.narg('viewer_id')::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = sqlc.narg('viewer_id')::uuid AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id')::uuid)
+  ))
+ORDER BY ancestors.depth DESC;
+
+-- name: GetChirpDescendants :many
+WITH RECURSIVE descendants (id, depth, path) AS (
+  SELECT chirps.id, 1, ARRAY[to_char(chirps.created_at, 'YYYYMMDDHH24MISSUS') || chirps.id::text]
+  FROM chirps
+  WHERE chirps.in_reply_to_id = sqlc.arg('root_id')
+    AND (sqlc.narg('viewer_id')::uuid IS NULL OR NOT EXISTS (
+      SELECT 1 FROM blocks
+      WHERE (blocks.blocker_id = sqlc.narg('viewer_id')::uuid AND blocks.blocked_id = chirps.user_id)
+        OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id')::uuid)
+    ))
+  UNION ALL
+  SELECT chirps.id, descendants.depth + 1, descendants.path || (to_char(chirps.created_at, 'YYYYMMDDHH24MISSUS') || chirps.id::text)
+  FROM chirps
+  JOIN descendants ON chirps.in_reply_to_id = descendants.id
+  WHERE sqlc.narg('viewer_id')::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = sqlc.narg('viewer_id')::uuid AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id')::uuid)
+  )
+)
+SELECT sqlc.embed(chirps), descendants.depth FROM chirps
+JOIN descendants ON chirps.id = descendants.id
+ORDER BY descendants.path
+LIMIT sqlc.arg('row_limit')
+OFFSET sqlc.arg('row_offset');
+
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg('ids')::uuid[])
+  AND (sqlc.narg('viewer_id')::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = sqlc.narg('viewer_id')::uuid AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id')::uuid)
+  ))
+  AND (sqlc.narg('viewer_id')::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = sqlc.narg('viewer_id')::uuid
+      AND mutes.muted_id = chirps.user_id
+  ));
+
+-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, rechirp_of_id)
+VALUES (
+  gen_random_uuid(),
+  NOW(),
+  NOW(),
+  '',
+  $1,
+  $2
+)
+ON CONFLICT (user_id, rechirp_of_id) WHERE rechirp_of_id IS NOT NULL DO NOTHING
+RETURNING *;
+
+-- name: DeleteRechirp :one
+DELETE FROM chirps
+WHERE user_id = $1
+  AND rechirp_of_id = $2
+RETURNING *;
+
+-- name: DeleteRechirpsOf :exec
+DELETE FROM chirps
+WHERE rechirp_of_id = $1;
+
+-- name: SoftDeleteChirp :exec
+UPDATE chirps
+SET deleted_at = NOW()
+WHERE (id = $1 OR rechirp_of_id = $1)
+  AND deleted_at IS NULL;
+
+-- name: RestoreChirp :exec
+UPDATE chirps
+SET deleted_at = NULL
+WHERE id = $1
+  OR rechirp_of_id = $1;
+
+-- name: GetTrashedChirp :one
+SELECT * FROM chirps
+WHERE id = $1
+  AND user_id = $2
+  AND deleted_at IS NOT NULL
+  AND tombstoned_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND rechirp_of_id IS NULL;
+
+-- name: ListTrashedChirps :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg('user_id')
+  AND deleted_at IS NOT NULL
+  AND tombstoned_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND rechirp_of_id IS NULL
+  AND (sqlc.narg('before_deleted_at')::timestamp IS NULL
+    OR (deleted_at, id) < (sqlc.narg('before_deleted_at')::timestamp, sqlc.narg('before_id')::uuid))
+ORDER BY deleted_at DESC, id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: ClaimPurgeableChirp :one
+SELECT * FROM chirps
+WHERE deleted_at < NOW() - make_interval(secs => sqlc.arg('max_age_seconds')::float8)
+  AND rechirp_of_id IS NULL
+ORDER BY deleted_at
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: ClaimExpiredChirp :one
+SELECT * FROM chirps
+WHERE expires_at <= NOW()
+ORDER BY expires_at
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: SetChirpSensitive :one
+UPDATE chirps
+SET
+  sensitive = $2,
+  sensitive_forced = $3,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING *;
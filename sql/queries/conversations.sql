@@ -0,0 +1,132 @@
+-- name: CreateConversation :one
+INSERT INTO conversations (id, created_at, updated_at, direct_key)
+VALUES (
+  gen_random_uuid(),
+  NOW(),
+  NOW(),
+  $1
+)
+ON CONFLICT (direct_key) DO NOTHING
+RETURNING *;
+
+-- name: GetDirectConversation :one
+SELECT * FROM conversations
+WHERE direct_key = $1;
+
+-- name: AddConversationParticipants :exec
+INSERT INTO conversation_participants (conversation_id, user_id, joined_at)
+SELECT sqlc.arg('conversation_id')::uuid, UNNEST(sqlc.arg('user_ids')::uuid[]), NOW()
+ON CONFLICT DO NOTHING;
+
+-- name: GetConversationParticipant :one
+SELECT * FROM conversation_participants
+WHERE conversation_id = $1
+  AND user_id = $2;
+
+-- name: GetConversationParticipants :many
+SELECT sqlc.embed(conversation_participants), users.handle FROM conversation_participants
+JOIN users ON users.id = conversation_participants.user_id
+WHERE conversation_participants.conversation_id = ANY(sqlc.arg('conversation_ids')::uuid[])
+ORDER BY conversation_participants.joined_at, conversation_participants.user_id;
+
+-- name: GetDMRecipients :many
+SELECT users.id, ((users.allow_dms_from_strangers OR EXISTS (
+  SELECT 1 FROM follows
+  WHERE follows.follower_id = users.id
+    AND follows.followee_id = sqlc.arg('sender_id')
+)) AND NOT EXISTS (
+  SELECT 1 FROM blocks
+  WHERE (blocks.blocker_id = users.id AND blocks.blocked_id = sqlc.arg('sender_id'))
+    OR (blocks.blocker_id = sqlc.arg('sender_id') AND blocks.blocked_id = users.id)
+))::boolean AS accepts
+FROM users
+WHERE users.id = ANY(sqlc.arg('user_ids')::uuid[]);
+
+-- name: ListConversations :many
+SELECT sqlc.embed(conversations), (
+  SELECT COUNT(*) FROM messages
+  WHERE messages.conversation_id = conversations.id
+    AND messages.sender_id <> conversation_participants.user_id
+    AND messages.created_at > COALESCE(GREATEST(conversation_participants.last_read_at, conversation_participants.cleared_at), '-infinity'::timestamp)
+    AND NOT EXISTS (
+      SELECT 1 FROM message_deletions
+      WHERE message_deletions.message_id = messages.id
+        AND message_deletions.user_id = conversation_participants.user_id
+    )
+) AS unread_count
+FROM conversations
+JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id
+WHERE conversation_participants.user_id = sqlc.arg('user_id')
+  AND (conversation_participants.cleared_at IS NULL OR conversations.updated_at > conversation_participants.cleared_at)
+  AND (sqlc.narg('before_updated_at')::timestamp IS NULL
+    OR (conversations.updated_at, conversations.id) < (sqlc.narg('before_updated_at')::timestamp, sqlc.narg('before_id')::uuid))
+ORDER BY conversations.updated_at DESC, conversations.id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: TouchConversation :exec
+UPDATE conversations
+SET
+  updated_at = NOW()
+WHERE id = $1;
+
+-- name: CreateMessage :one
+INSERT INTO messages (id, conversation_id, sender_id, body, created_at)
+VALUES (
+  gen_random_uuid(),
+  $1,
+  $2,
+  $3,
+  NOW()
+)
+RETURNING *;
+
+-- name: ListMessages :many
+SELECT messages.* FROM messages
+JOIN conversation_participants ON conversation_participants.conversation_id = messages.conversation_id
+  AND conversation_participants.user_id = sqlc.arg('user_id')
+WHERE messages.conversation_id = sqlc.arg('conversation_id')
+  AND (conversation_participants.cleared_at IS NULL OR messages.created_at > conversation_participants.cleared_at)
+  AND NOT EXISTS (
+    SELECT 1 FROM message_deletions
+    WHERE message_deletions.message_id = messages.id
+      AND message_deletions.user_id = sqlc.arg('user_id')
+  )
+  AND (sqlc.narg('before_created_at')::timestamp IS NULL
+    OR (messages.created_at, messages.id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_id')::uuid))
+ORDER BY messages.created_at DESC, messages.id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: GetLatestMessages :many
+SELECT DISTINCT ON (messages.conversation_id) messages.* FROM messages
+JOIN conversation_participants ON conversation_participants.conversation_id = messages.conversation_id
+  AND conversation_participants.user_id = sqlc.arg('user_id')
+WHERE messages.conversation_id = ANY(sqlc.arg('conversation_ids')::uuid[])
+  AND (conversation_participants.cleared_at IS NULL OR messages.created_at > conversation_participants.cleared_at)
+  AND NOT EXISTS (
+    SELECT 1 FROM message_deletions
+    WHERE message_deletions.message_id = messages.id
+      AND message_deletions.user_id = sqlc.arg('user_id')
+  )
+ORDER BY messages.conversation_id, messages.created_at DESC, messages.id DESC;
+
+-- name: MarkConversationRead :exec
+UPDATE conversation_participants
+SET
+  last_read_at = NOW()
+WHERE conversation_id = $1
+  AND user_id = $2;
+
+-- name: ClearConversation :exec
+UPDATE conversation_participants
+SET
+  cleared_at = NOW(),
+  last_read_at = NOW()
+WHERE conversation_id = $1
+  AND user_id = $2;
+
+-- name: DeleteMessageForUser :execrows
+INSERT INTO message_deletions (message_id, user_id, created_at)
+SELECT messages.id, sqlc.arg('user_id')::uuid, NOW() FROM messages
+WHERE messages.id = sqlc.arg('message_id')
+  AND messages.conversation_id = sqlc.arg('conversation_id')
+ON CONFLICT DO NOTHING;
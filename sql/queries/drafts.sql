@@ -0,0 +1,65 @@
+-- name: CreateDraft :one
+INSERT INTO drafts (id, user_id, body, in_reply_to_id, quoted_chirp_id, media_ids, publish_at, created_at, updated_at)
+VALUES (
+  gen_random_uuid(),
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  $6,
+  NOW(),
+  NOW()
+)
+RETURNING *;
+
+-- name: CountDrafts :one
+SELECT COUNT(*) FROM drafts
+WHERE user_id = $1;
+
+-- name: ListDrafts :many
+SELECT * FROM drafts
+WHERE user_id = sqlc.arg('user_id')
+  AND (publish_at IS NOT NULL) = sqlc.arg('scheduled')::bool
+ORDER BY publish_at, updated_at DESC;
+
+-- name: UpdateDraft :one
+UPDATE drafts
+SET
+  body = sqlc.arg('body'),
+  in_reply_to_id = sqlc.arg('in_reply_to_id'),
+  quoted_chirp_id = sqlc.arg('quoted_chirp_id'),
+  media_ids = sqlc.arg('media_ids'),
+  publish_at = sqlc.narg('publish_at'),
+  last_error = '',
+  updated_at = NOW()
+WHERE id = sqlc.arg('id')
+  AND user_id = sqlc.arg('user_id')
+  AND (publish_at IS NOT NULL) = sqlc.arg('scheduled')::bool
+RETURNING *;
+
+-- name: DeleteDraft :execrows
+DELETE FROM drafts
+WHERE id = sqlc.arg('id')
+  AND user_id = sqlc.arg('user_id')
+  AND (publish_at IS NOT NULL) = sqlc.arg('scheduled')::bool;
+
+-- name: ClaimDueDraft :one
+SELECT * FROM drafts
+WHERE publish_at <= NOW()
+  AND NOT id = ANY(sqlc.arg('skipped_ids')::uuid[])
+ORDER BY publish_at
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: RemovePublishedDraft :exec
+DELETE FROM drafts
+WHERE id = $1;
+
+-- name: FailScheduledDraft :exec
+UPDATE drafts
+SET
+  publish_at = NULL,
+  last_error = $2,
+  updated_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,35 @@
+-- name: FollowUser :execrows
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES (
+  $1,
+  $2,
+  NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :execrows
+DELETE FROM follows
+WHERE follower_id = $1
+  AND followee_id = $2;
+
+-- name: ListFollowers :many
+SELECT follower_id, created_at FROM follows
+WHERE followee_id = $1
+ORDER BY created_at DESC, follower_id
+LIMIT $2
+OFFSET $3;
+
+-- name: ListFollowing :many
+SELECT followee_id, created_at FROM follows
+WHERE follower_id = $1
+ORDER BY created_at DESC, followee_id
+LIMIT $2
+OFFSET $3;
+
+-- name: CountFollowers :one
+SELECT COUNT(*) FROM follows
+WHERE followee_id = $1;
+
+-- name: CountFollowing :one
+SELECT COUNT(*) FROM follows
+WHERE follower_id = $1;
@@ -0,0 +1,50 @@
+-- name: CreateHashtags :exec
+INSERT INTO hashtags (tag, created_at)
+SELECT unnest(sqlc.arg('tags')::text[]), NOW()
+ON CONFLICT DO NOTHING;
+
+-- name: TagChirp :exec
+INSERT INTO chirp_hashtags (chirp_id, tag, created_at)
+SELECT chirps.id, unnest(sqlc.arg('tags')::text[]), chirps.created_at
+FROM chirps
+WHERE chirps.id = sqlc.arg('chirp_id')
+ON CONFLICT DO NOTHING;
+
+-- name: GetChirpsByHashtag :many
+SELECT chirps.* FROM chirp_hashtags
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirp_hashtags.tag = sqlc.arg('tag')
+  AND chirps.tombstoned_at IS NULL
+  AND chirps.deleted_at IS NULL
+  AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW())
+  AND (sqlc.narg('before_created_at')::timestamp IS NULL
+    OR (chirp_hashtags.created_at, chirp_hashtags.chirp_id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_id')::uuid))
+  AND (sqlc.narg('viewer_id')::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = sqlc.narg('viewer_id')::uuid AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id')::uuid)
+  ))
+  AND (sqlc.narg('viewer_id')::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = sqlc.narg('viewer_id')::uuid
+      AND mutes.muted_id = chirps.user_id
+  ))
+ORDER BY chirp_hashtags.created_at DESC, chirp_hashtags.chirp_id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: GetTrendingHashtags :many
+SELECT
+  chirp_hashtags.tag,
+  COUNT(*) AS uses,
+  SUM(
+    POWER(0.5, EXTRACT(EPOCH FROM (NOW() - chirp_hashtags.created_at)) / sqlc.arg('half_life_seconds')::float8)
+  )::float8 AS score
+FROM chirp_hashtags
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirp_hashtags.created_at > NOW() - make_interval(secs => sqlc.arg('window_seconds')::float8)
+  AND chirps.tombstoned_at IS NULL
+  AND chirps.deleted_at IS NULL
+  AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW())
+GROUP BY chirp_hashtags.tag
+ORDER BY score DESC, uses DESC, chirp_hashtags.tag
+LIMIT sqlc.arg('row_limit');
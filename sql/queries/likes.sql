@@ -0,0 +1,33 @@
+-- name: LikeChirp :exec
+INSERT INTO likes (user_id, chirp_id, created_at)
+VALUES (
+  $1,
+  $2,
+  NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnlikeChirp :execrows
+DELETE FROM likes
+WHERE user_id = $1
+  AND chirp_id = $2;
+
+-- name: ListLikers :many
+SELECT user_id, created_at FROM likes
+WHERE chirp_id = $1
+ORDER BY created_at DESC, user_id
+LIMIT $2
+OFFSET $3;
+
+-- name: CountLikes :one
+SELECT COUNT(*) FROM likes
+WHERE chirp_id = $1;
+
+-- name: GetLikeSummaries :many
+SELECT
+  chirp_id,
+  COUNT(*) AS like_count,
+  COALESCE(BOOL_OR(user_id = sqlc.narg('viewer_id')::uuid), FALSE)::boolean AS liked_by_viewer
+FROM likes
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+GROUP BY chirp_id;
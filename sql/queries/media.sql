@@ -0,0 +1,55 @@
+-- name: CreateMedia :one
+INSERT INTO media (id, user_id, content_type, width, height, alt_text, blurhash, created_at)
+VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  $6,
+  $7,
+  NOW()
+)
+RETURNING *;
+
+-- name: UpdateMediaAltText :one
+UPDATE media
+SET alt_text = $3
+WHERE id = $1
+  AND user_id = $2
+RETURNING *;
+
+-- name: AttachMedia :execrows
+UPDATE media
+SET
+  chirp_id = sqlc.arg('chirp_id'),
+  position = array_position(sqlc.arg('ids')::uuid[], id)
+WHERE id = ANY(sqlc.arg('ids')::uuid[])
+  AND user_id = sqlc.arg('user_id')
+  AND chirp_id IS NULL;
+
+-- name: CountUsableMedia :one
+SELECT COUNT(*) FROM media
+WHERE id = ANY(sqlc.arg('ids')::uuid[])
+  AND user_id = sqlc.arg('user_id')
+  AND chirp_id IS NULL;
+
+-- name: DetachChirpMedia :exec
+UPDATE media
+SET chirp_id = NULL
+WHERE chirp_id = $1;
+
+-- name: GetMediaForChirps :many
+SELECT * FROM media
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: DeleteOrphanedMedia :many
+DELETE FROM media
+WHERE chirp_id IS NULL
+  AND created_at < NOW() - make_interval(secs => sqlc.arg('max_age_seconds')::float8)
+  AND NOT EXISTS (
+    SELECT 1 FROM drafts
+    WHERE media.id = ANY(drafts.media_ids)
+  )
+RETURNING *;
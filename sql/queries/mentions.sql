@@ -0,0 +1,49 @@
+-- name: AddChirpMentions :many
+WITH added AS (
+  INSERT INTO chirp_mentions (chirp_id, user_id, handle, created_at)
+  SELECT chirps.id, users.id, LOWER(users.handle), chirps.created_at
+  FROM chirps
+  JOIN users ON LOWER(users.handle) = ANY(sqlc.arg('handles')::text[])
+  WHERE chirps.id = sqlc.arg('chirp_id')
+  ON CONFLICT DO NOTHING
+  RETURNING chirp_id, user_id
+)
+SELECT added.user_id FROM added
+JOIN chirps ON chirps.id = added.chirp_id
+WHERE added.user_id <> chirps.user_id
+  AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = added.user_id AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = added.user_id)
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = added.user_id
+      AND mutes.muted_id = chirps.user_id
+  );
+
+-- name: GetChirpMentions :many
+SELECT * FROM chirp_mentions
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: GetMentionedChirps :many
+SELECT chirps.* FROM chirp_mentions
+JOIN chirps ON chirps.id = chirp_mentions.chirp_id
+WHERE chirp_mentions.user_id = sqlc.arg('user_id')
+  AND chirps.tombstoned_at IS NULL
+  AND chirps.deleted_at IS NULL
+  AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW())
+  AND (sqlc.narg('before_created_at')::timestamp IS NULL
+    OR (chirp_mentions.created_at, chirp_mentions.chirp_id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_id')::uuid))
+  AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = sqlc.arg('user_id') AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.arg('user_id'))
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = sqlc.arg('user_id')
+      AND mutes.muted_id = chirps.user_id
+  )
+ORDER BY chirp_mentions.created_at DESC, chirp_mentions.chirp_id DESC
+LIMIT sqlc.arg('row_limit');
@@ -0,0 +1,42 @@
+-- name: ListModerationWords :many
+SELECT * FROM moderation_words
+ORDER BY word;
+
+-- name: SetModerationWord :one
+INSERT INTO moderation_words (word, action, created_at, updated_at)
+VALUES (
+  $1,
+  $2,
+  NOW(),
+  NOW()
+)
+ON CONFLICT (word) DO UPDATE SET action = EXCLUDED.action, updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteModerationWord :execrows
+DELETE FROM moderation_words
+WHERE word = $1;
+
+-- name: FlagChirp :exec
+INSERT INTO moderation_flags (chirp_id, words, created_at)
+VALUES (
+  $1,
+  $2,
+  NOW()
+)
+ON CONFLICT (chirp_id) DO UPDATE SET words = EXCLUDED.words, created_at = NOW();
+
+-- name: ListFlaggedChirps :many
+SELECT sqlc.embed(chirps), moderation_flags.words, moderation_flags.created_at AS flagged_at FROM moderation_flags
+JOIN chirps ON chirps.id = moderation_flags.chirp_id
+WHERE chirps.tombstoned_at IS NULL
+  AND chirps.deleted_at IS NULL
+  AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW())
+  AND (sqlc.narg('before_created_at')::timestamp IS NULL
+    OR (moderation_flags.created_at, moderation_flags.chirp_id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_id')::uuid))
+ORDER BY moderation_flags.created_at DESC, moderation_flags.chirp_id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: DismissFlag :execrows
+DELETE FROM moderation_flags
+WHERE chirp_id = $1;
@@ -0,0 +1,40 @@
+-- name: CreateNotification :one
+INSERT INTO notifications (id, user_id, type, data, created_at, read_at)
+VALUES (
+  gen_random_uuid(),
+  $1,
+  $2,
+  $3,
+  NOW(),
+  NULL
+)
+RETURNING *;
+
+-- name: ListNotifications :many
+SELECT * FROM notifications
+WHERE user_id = sqlc.arg('user_id')
+  AND (sqlc.narg('type')::text IS NULL OR type = sqlc.narg('type')::text)
+  AND (NOT sqlc.arg('unread_only')::boolean OR read_at IS NULL)
+  AND (sqlc.narg('before_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications
+SET
+  read_at = COALESCE(read_at, NOW())
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: MarkAllNotificationsRead :exec
+UPDATE notifications
+SET
+  read_at = NOW()
+WHERE user_id = $1
+  AND read_at IS NULL;
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = $1
+  AND read_at IS NULL;
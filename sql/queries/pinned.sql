@@ -0,0 +1,45 @@
+-- name: PinChirp :exec
+INSERT INTO pinned_chirps (user_id, chirp_id, created_at)
+VALUES (
+  $1,
+  $2,
+  NOW()
+)
+ON CONFLICT (user_id, chirp_id) DO UPDATE SET created_at = NOW();
+
+-- name: TrimPinnedChirps :exec
+DELETE FROM pinned_chirps
+WHERE pinned_chirps.user_id = sqlc.arg('user_id')
+  AND pinned_chirps.chirp_id NOT IN (
+    SELECT newest.chirp_id FROM pinned_chirps AS newest
+    WHERE newest.user_id = sqlc.arg('user_id')
+    ORDER BY newest.created_at DESC
+    LIMIT sqlc.arg('keep')
+  );
+
+-- name: UnpinChirp :execrows
+DELETE FROM pinned_chirps
+WHERE user_id = $1
+  AND chirp_id = $2;
+
+-- name: UnpinAllChirps :execrows
+DELETE FROM pinned_chirps
+WHERE user_id = $1;
+
+-- name: UnpinChirpEverywhere :exec
+DELETE FROM pinned_chirps
+WHERE chirp_id = $1;
+
+-- name: GetPinnedChirps :many
+SELECT chirps.* FROM pinned_chirps
+JOIN chirps ON chirps.id = pinned_chirps.chirp_id
+WHERE pinned_chirps.user_id = sqlc.arg('user_id')
+  AND chirps.tombstoned_at IS NULL
+  AND chirps.deleted_at IS NULL
+  AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW())
+  AND (sqlc.narg('viewer_id')::uuid IS NULL OR NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = sqlc.narg('viewer_id')::uuid AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id')::uuid)
+  ))
+ORDER BY pinned_chirps.created_at DESC;
@@ -0,0 +1,42 @@
+-- name: CreatePoll :exec
+INSERT INTO polls (chirp_id, closes_at, created_at)
+VALUES (
+  sqlc.arg('chirp_id'),
+  NOW() + make_interval(secs => sqlc.arg('duration_seconds')::float8),
+  NOW()
+);
+
+-- name: AddPollOptions :exec
+INSERT INTO poll_options (chirp_id, position, text)
+SELECT sqlc.arg('chirp_id')::uuid, options.ordinality - 1, options.text
+FROM unnest(sqlc.arg('options')::text[]) WITH ORDINALITY AS options (text, ordinality);
+
+-- name: GetPoll :one
+SELECT * FROM polls
+WHERE chirp_id = $1;
+
+-- name: GetPolls :many
+SELECT * FROM polls
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: GetPollResults :many
+SELECT poll_options.chirp_id, poll_options.position, poll_options.text, COUNT(poll_votes.user_id) AS votes
+FROM poll_options
+LEFT JOIN poll_votes ON poll_votes.chirp_id = poll_options.chirp_id
+  AND poll_votes.position = poll_options.position
+WHERE poll_options.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+GROUP BY poll_options.chirp_id, poll_options.position
+ORDER BY poll_options.chirp_id, poll_options.position;
+
+-- name: GetPollVotesByUser :many
+SELECT chirp_id, position FROM poll_votes
+WHERE user_id = sqlc.arg('user_id')
+  AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: CastPollVote :execrows
+INSERT INTO poll_votes (chirp_id, user_id, position, created_at)
+SELECT polls.chirp_id, sqlc.arg('user_id')::uuid, sqlc.arg('position')::int, NOW()
+FROM polls
+WHERE polls.chirp_id = sqlc.arg('chirp_id')
+  AND polls.closes_at > NOW()
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,33 @@
+-- name: EditChirp :one
+UPDATE chirps
+SET
+  body = $2,
+  updated_at = NOW(),
+  edited_at = NOW()
+WHERE id = $1
+  AND (expires_at IS NULL OR expires_at > NOW())
+RETURNING *;
+
+-- name: SaveChirpRevision :exec
+INSERT INTO chirp_revisions (id, chirp_id, body, created_at, replaced_at)
+SELECT gen_random_uuid(), chirps.id, chirps.body, COALESCE(chirps.edited_at, chirps.created_at), NOW()
+FROM chirps
+WHERE chirps.id = $1;
+
+-- name: GetChirpRevisions :many
+SELECT * FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY created_at DESC;
+
+-- name: DeleteChirpRevisions :exec
+DELETE FROM chirp_revisions
+WHERE chirp_id = $1;
+
+-- name: UntagChirp :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_id = $1;
+
+-- name: RemoveStaleMentions :exec
+DELETE FROM chirp_mentions
+WHERE chirp_id = sqlc.arg('chirp_id')
+  AND NOT (handle = ANY(sqlc.arg('handles')::text[]));
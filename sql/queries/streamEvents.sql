@@ -0,0 +1,28 @@
+-- name: CreateStreamEvent :one
+INSERT INTO stream_events (type, author_id, hashtags, data, created_at, embedded_author_ids)
+VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  NOW(),
+  $5
+)
+RETURNING *;
+
+-- name: GetStreamEventsAfter :many
+SELECT * FROM stream_events
+WHERE id > $1
+ORDER BY id ASC
+LIMIT $2;
+
+-- name: NotifyStreamEvent :exec
+SELECT pg_notify(sqlc.arg('channel')::text, sqlc.arg('payload')::text);
+
+-- name: DeleteOldStreamEvents :execrows
+DELETE FROM stream_events
+WHERE created_at < NOW() - make_interval(secs => sqlc.arg('max_age_seconds')::float8);
+
+-- name: GetStreamEvent :one
+SELECT * FROM stream_events
+WHERE id = $1;
@@ -0,0 +1,65 @@
+-- name: FanOutChirp :exec
+INSERT INTO timeline_entries (user_id, chirp_id, created_at)
+SELECT chirps.user_id, chirps.id, chirps.created_at
+FROM chirps
+WHERE chirps.id = $1
+UNION ALL
+SELECT follows.follower_id, chirps.id, chirps.created_at
+FROM follows
+JOIN chirps ON chirps.user_id = follows.followee_id
+WHERE chirps.id = $1
+ON CONFLICT DO NOTHING;
+
+-- name: BackfillTimeline :exec
+INSERT INTO timeline_entries (user_id, chirp_id, created_at)
+SELECT sqlc.arg('follower_id')::uuid, chirps.id, chirps.created_at
+FROM chirps
+WHERE chirps.user_id = sqlc.arg('followee_id')
+  AND chirps.tombstoned_at IS NULL
+  AND chirps.deleted_at IS NULL
+  AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW())
+ORDER BY chirps.created_at DESC
+LIMIT 100
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveFolloweeFromTimeline :exec
+DELETE FROM timeline_entries
+USING chirps
+WHERE timeline_entries.chirp_id = chirps.id
+  AND timeline_entries.user_id = sqlc.arg('follower_id')
+  AND chirps.user_id = sqlc.arg('followee_id');
+
+-- name: GetTimeline :many
+SELECT chirps.* FROM timeline_entries
+JOIN chirps ON chirps.id = timeline_entries.chirp_id
+WHERE timeline_entries.user_id = sqlc.arg('user_id')
+  AND chirps.tombstoned_at IS NULL
+  AND chirps.deleted_at IS NULL
+  AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW())
+  AND (sqlc.narg('before_created_at')::timestamp IS NULL
+    OR (timeline_entries.created_at, timeline_entries.chirp_id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_id')::uuid))
+  AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = sqlc.arg('user_id') AND blocks.blocked_id = chirps.user_id)
+      OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.arg('user_id'))
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = sqlc.arg('user_id')
+      AND mutes.muted_id = chirps.user_id
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM chirps AS originals
+    WHERE originals.id = chirps.rechirp_of_id
+      AND (EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = sqlc.arg('user_id') AND blocks.blocked_id = originals.user_id)
+          OR (blocks.blocker_id = originals.user_id AND blocks.blocked_id = sqlc.arg('user_id'))
+      ) OR EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.arg('user_id')
+          AND mutes.muted_id = originals.user_id
+      ))
+  )
+ORDER BY timeline_entries.created_at DESC, timeline_entries.chirp_id DESC
+LIMIT sqlc.arg('row_limit');
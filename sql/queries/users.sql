@@ -1,11 +1,12 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES (
   gen_random_uuid(),
   NOW(),
   NOW(),
   $1,
-  $2
+  $2,
+  $3
 )
 RETURNING *;
 
@@ -31,3 +32,66 @@ SET
   is_chirpy_red = TRUE
 WHERE id = $1
 RETURNING *;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: SetUserHandle :one
+UPDATE users
+SET
+  handle = $2,
+  handle_changed_at = CASE WHEN handle IS NULL OR LOWER(handle) = LOWER($2) THEN handle_changed_at ELSE NOW() END,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetUserByHandle :one
+SELECT * FROM users
+WHERE LOWER(handle) = LOWER(sqlc.arg('handle'));
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET
+  display_name = $2,
+  bio = $3,
+  location = $4,
+  website = $5,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetProfileCounts :one
+SELECT
+  (SELECT COUNT(*) FROM chirps WHERE chirps.user_id = sqlc.arg('user_id') AND chirps.tombstoned_at IS NULL AND chirps.deleted_at IS NULL AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW())) AS chirp_count,
+  (SELECT COUNT(*) FROM follows WHERE follows.followee_id = sqlc.arg('user_id')) AS follower_count,
+  (SELECT COUNT(*) FROM follows WHERE follows.follower_id = sqlc.arg('user_id')) AS following_count;
+
+-- name: GetChirpAuthors :many
+SELECT id, handle, display_name, is_chirpy_red, avatar_id FROM users
+WHERE id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: UpdateUserSettings :one
+UPDATE users
+SET
+  allow_dms_from_strangers = $2,
+  expand_sensitive = $3,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUserAvatar :one
+UPDATE users
+SET
+  avatar_id = $2,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUserBanner :one
+UPDATE users
+SET
+  banner_id = $2,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING *;
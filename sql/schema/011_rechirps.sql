@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE chirps
+  ADD COLUMN quoted_chirp_id UUID REFERENCES chirps (id) ON DELETE SET NULL,
+  ADD COLUMN rechirp_of_id UUID REFERENCES chirps (id) ON DELETE CASCADE;
+
+CREATE INDEX chirps_quoted_chirp_id_idx ON chirps (quoted_chirp_id);
+CREATE INDEX chirps_rechirp_of_id_idx ON chirps (rechirp_of_id);
+CREATE UNIQUE INDEX chirps_user_id_rechirp_of_id_key ON chirps (user_id, rechirp_of_id)
+  WHERE rechirp_of_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_user_id_rechirp_of_id_key;
+DROP INDEX chirps_rechirp_of_id_idx;
+DROP INDEX chirps_quoted_chirp_id_idx;
+
+ALTER TABLE chirps
+  DROP COLUMN rechirp_of_id,
+  DROP COLUMN quoted_chirp_id;
@@ -0,0 +1,23 @@
+-- +goose Up
+ALTER TABLE users
+  ADD COLUMN handle TEXT;
+
+CREATE UNIQUE INDEX users_lower_handle_key ON users (LOWER(handle));
+
+CREATE TABLE chirp_mentions (
+  chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  handle TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (chirp_id, user_id)
+);
+
+CREATE INDEX chirp_mentions_user_id_created_at_idx ON chirp_mentions (user_id, created_at DESC, chirp_id DESC);
+
+-- +goose Down
+DROP TABLE chirp_mentions;
+
+DROP INDEX users_lower_handle_key;
+
+ALTER TABLE users
+DROP COLUMN handle;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE stream_events (
+  id BIGSERIAL PRIMARY KEY,
+  type TEXT NOT NULL,
+  author_id UUID NOT NULL,
+  hashtags TEXT[] NOT NULL DEFAULT '{}',
+  data JSONB NOT NULL,
+  created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX stream_events_created_at_idx ON stream_events (created_at);
+
+-- +goose Down
+DROP TABLE stream_events;
@@ -0,0 +1,47 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN allow_dms_from_strangers BOOLEAN NOT NULL DEFAULT TRUE;
+
+CREATE TABLE conversations (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  -- Only set for one-to-one conversations, it is both user IDs in order so that a pair only ever gets one conversation
+  direct_key TEXT UNIQUE
+);
+
+CREATE TABLE conversation_participants (
+  conversation_id UUID NOT NULL REFERENCES conversations (id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  joined_at TIMESTAMP NOT NULL,
+  last_read_at TIMESTAMP,
+  -- Messages from before cleared_at are hidden from this user, it is how one person deletes a conversation
+  cleared_at TIMESTAMP,
+  PRIMARY KEY (conversation_id, user_id)
+);
+
+CREATE INDEX conversation_participants_user_id_idx ON conversation_participants (user_id);
+
+CREATE TABLE messages (
+  id UUID PRIMARY KEY,
+  conversation_id UUID NOT NULL REFERENCES conversations (id) ON DELETE CASCADE,
+  sender_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  body TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX messages_conversation_id_created_at_idx ON messages (conversation_id, created_at DESC, id DESC);
+
+-- A message that one user deleted, everyone else still sees it
+CREATE TABLE message_deletions (
+  message_id UUID NOT NULL REFERENCES messages (id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (message_id, user_id)
+);
+
+-- +goose Down
+DROP TABLE message_deletions;
+DROP TABLE messages;
+DROP TABLE conversation_participants;
+DROP TABLE conversations;
+ALTER TABLE users DROP COLUMN allow_dms_from_strangers;
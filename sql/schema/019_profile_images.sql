@@ -0,0 +1,10 @@
+-- +goose Up
+-- Every upload gets a new ID so the image URLs never change and can be cached forever
+ALTER TABLE users
+  ADD COLUMN avatar_id UUID,
+  ADD COLUMN banner_id UUID;
+
+-- +goose Down
+ALTER TABLE users
+  DROP COLUMN banner_id,
+  DROP COLUMN avatar_id;
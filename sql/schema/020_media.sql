@@ -0,0 +1,21 @@
+-- +goose Up
+-- Media is uploaded on its own first and attached when the chirp is created. chirp_id stays NULL until then, and goes
+-- back to NULL when the chirp is deleted, so the cleaner can remove the files
+CREATE TABLE media (
+  id UUID PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  chirp_id UUID REFERENCES chirps (id) ON DELETE SET NULL,
+  position INT NOT NULL DEFAULT 0,
+  content_type TEXT NOT NULL,
+  width INT NOT NULL,
+  height INT NOT NULL,
+  alt_text TEXT NOT NULL DEFAULT '',
+  blurhash TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX media_chirp_id_idx ON media (chirp_id, position);
+CREATE INDEX media_orphans_idx ON media (created_at) WHERE chirp_id IS NULL;
+
+-- +goose Down
+DROP TABLE media;
@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE chirps
+  ADD COLUMN edited_at TIMESTAMP;
+
+-- Every body a chirp had before an edit. created_at is when that version was written and replaced_at is when the edit
+-- replaced it
+CREATE TABLE chirp_revisions (
+  id UUID PRIMARY KEY,
+  chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+  body TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  replaced_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX chirp_revisions_chirp_id_idx ON chirp_revisions (chirp_id, created_at);
+
+-- +goose Down
+DROP TABLE chirp_revisions;
+
+ALTER TABLE chirps
+  DROP COLUMN edited_at;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- A draft with a publish_at is a scheduled chirp. The chirps being replied to or quoted aren't foreign keys since they
+-- can be deleted before the draft goes out, that gets checked when it's published instead
+CREATE TABLE drafts (
+  id UUID PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  body TEXT NOT NULL,
+  in_reply_to_id UUID,
+  quoted_chirp_id UUID,
+  media_ids UUID[] NOT NULL DEFAULT '{}',
+  publish_at TIMESTAMP,
+  last_error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX drafts_user_id_idx ON drafts (user_id, updated_at);
+CREATE INDEX drafts_publish_at_idx ON drafts (publish_at) WHERE publish_at IS NOT NULL;
+
+-- +goose Down
+DROP TABLE drafts;
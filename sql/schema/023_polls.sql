@@ -0,0 +1,31 @@
+-- +goose Up
+-- A poll belongs to a single chirp. It closes by itself once closes_at has passed
+CREATE TABLE polls (
+  chirp_id UUID PRIMARY KEY REFERENCES chirps (id) ON DELETE CASCADE,
+  closes_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE poll_options (
+  chirp_id UUID NOT NULL REFERENCES polls (chirp_id) ON DELETE CASCADE,
+  position INT NOT NULL,
+  text TEXT NOT NULL,
+  PRIMARY KEY (chirp_id, position)
+);
+
+-- The primary key is what keeps it to one vote per user
+CREATE TABLE poll_votes (
+  chirp_id UUID NOT NULL,
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  position INT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (chirp_id, user_id),
+  FOREIGN KEY (chirp_id, position) REFERENCES poll_options (chirp_id, position) ON DELETE CASCADE
+);
+
+CREATE INDEX poll_votes_user_id_idx ON poll_votes (user_id);
+
+-- +goose Down
+DROP TABLE poll_votes;
+DROP TABLE poll_options;
+DROP TABLE polls;
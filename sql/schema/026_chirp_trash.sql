@@ -0,0 +1,12 @@
+-- +goose Up
+-- Deleted chirps sit in their author's trash with deleted_at set until they're restored or purged
+ALTER TABLE chirps
+  ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX chirps_deleted_at_idx ON chirps (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_deleted_at_idx;
+
+ALTER TABLE chirps
+  DROP COLUMN deleted_at;
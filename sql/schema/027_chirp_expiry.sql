@@ -0,0 +1,12 @@
+-- +goose Up
+-- Ephemeral chirps stop showing up at expires_at and are deleted by the sweeper soon after
+ALTER TABLE chirps
+  ADD COLUMN expires_at TIMESTAMP;
+
+CREATE INDEX chirps_expires_at_idx ON chirps (expires_at) WHERE expires_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_expires_at_idx;
+
+ALTER TABLE chirps
+  DROP COLUMN expires_at;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- sensitive_forced is set when a moderator marked the chirp as sensitive, so that its author can't take it off again
+ALTER TABLE chirps
+  ADD COLUMN content_warning TEXT NOT NULL DEFAULT '',
+  ADD COLUMN sensitive BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN sensitive_forced BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- There's no endpoint for making someone a moderator, it's set straight in the database
+ALTER TABLE users
+  ADD COLUMN is_moderator BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN expand_sensitive BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE users
+  DROP COLUMN expand_sensitive,
+  DROP COLUMN is_moderator;
+
+ALTER TABLE chirps
+  DROP COLUMN sensitive_forced,
+  DROP COLUMN sensitive,
+  DROP COLUMN content_warning;
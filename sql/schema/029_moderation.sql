@@ -0,0 +1,28 @@
+-- +goose Up
+-- Banned words added through the admin endpoints. They go on top of the word list file, if there is one
+CREATE TABLE moderation_words (
+  word TEXT PRIMARY KEY,
+  action TEXT NOT NULL CHECK (action IN ('mask', 'reject', 'flag')),
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL
+);
+
+-- The words that were hardcoded before the filter could be configured
+INSERT INTO moderation_words (word, action, created_at, updated_at)
+VALUES
+  ('kerfuffle', 'mask', NOW(), NOW()),
+  ('sharbert', 'mask', NOW(), NOW()),
+  ('fornax', 'mask', NOW(), NOW());
+
+-- Chirps waiting for a moderator to look at them because they used a flagged word
+CREATE TABLE moderation_flags (
+  chirp_id UUID PRIMARY KEY REFERENCES chirps (id) ON DELETE CASCADE,
+  words TEXT[] NOT NULL,
+  created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX moderation_flags_created_at_idx ON moderation_flags (created_at, chirp_id);
+
+-- +goose Down
+DROP TABLE moderation_flags;
+DROP TABLE moderation_words;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- The authors of the chirps that a rechirp or quote embeds, so that streams can leave them out for users on either side
+-- of a block with them, or that have muted them
+ALTER TABLE stream_events
+  ADD COLUMN embedded_author_ids UUID[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE stream_events
+  DROP COLUMN embedded_author_ids;
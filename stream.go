@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/vilebile17/chirpy/internal/auth"
+	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/entities"
+	"github.com/vilebile17/chirpy/internal/pubsub"
+)
+
+const (
+	streamChannel           = "chirp_events"
+	streamHeartbeatInterval = 15 * time.Second
+	streamBacklogLimit      = 500
+	streamEventMaxAge       = 24 * time.Hour
+	streamBufferSize        = 64
+	// streamHiddenUsersRefresh is how often a stream picks up the blocks and mutes made since it connected
+	streamHiddenUsersRefresh = time.Minute
+)
+
+// publishChirpEvent saves the event (so that clients can resume from it) and then publishes it on the hub.
+// The event ID comes from the database so it means the same thing on every server
+func (config *apiConfig) publishChirpEvent(ctx context.Context, eventType string, chirp Chirp, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	embeddedAuthorIDs := []uuid.UUID{}
+	for _, embedded := range []*Chirp{chirp.RechirpOf, chirp.QuotedChirp} {
+		if embedded != nil {
+			embeddedAuthorIDs = append(embeddedAuthorIDs, embedded.UserID)
+		}
+	}
+
+	sqlEvent, err := config.dbQueries.CreateStreamEvent(ctx, database.CreateStreamEventParams{
+		Type:              eventType,
+		AuthorID:          chirp.UserID,
+		Hashtags:          entities.Hashtags(chirp.Body),
+		Data:              encoded,
+		EmbeddedAuthorIDs: embeddedAuthorIDs,
+	})
+	if err != nil {
+		return err
+	}
+
+	config.hub.Publish(eventFromDatabase(sqlEvent))
+	return nil
+}
+
+// publishChirp publishes the chirp as someone who isn't logged in would see it. The event goes to every subscriber, so
+// nothing that depends on the viewer (like their likes or the results of a poll they haven't voted on) can be in it
+func (config *apiConfig) publishChirp(ctx context.Context, eventType string, sqlChirp database.Chirp) error {
+	chirp := chirpFromDatabase(sqlChirp)
+	if err := config.hydrateChirps(ctx, uuid.NullUUID{}, []*Chirp{&chirp}); err != nil {
+		return err
+	}
+	return config.publishChirpEvent(ctx, eventType, chirp, chirp)
+}
+
+// publishChirpDeleted tells the streams that a chirp has gone, only its ID and author are sent
+func (config *apiConfig) publishChirpDeleted(ctx context.Context, sqlChirp database.Chirp) error {
+	return config.publishChirpEvent(ctx, pubsub.EventChirpDeleted, chirpFromDatabase(sqlChirp), struct {
+		ID     uuid.UUID `json:"id"`
+		UserID uuid.UUID `json:"user_id"`
+	}{
+		sqlChirp.ID,
+		sqlChirp.UserID,
+	})
+}
+
+func eventFromDatabase(event database.StreamEvent) pubsub.Event {
+	return pubsub.Event{
+		ID:                event.ID,
+		Type:              event.Type,
+		AuthorID:          event.AuthorID,
+		Hashtags:          event.Hashtags,
+		Data:              event.Data,
+		EmbeddedAuthorIDs: event.EmbeddedAuthorIDs,
+	}
+}
+
+// notifyOtherServers is given every event published on the hub and passes it on with NOTIFY. NOTIFY payloads can't be
+// more than 8000 bytes, so saved events only send their ID and the other servers load the rest from the database
+func (config *apiConfig) notifyOtherServers(event pubsub.Event) {
+	if event.ID != 0 {
+		event = pubsub.Event{ID: event.ID, Origin: event.Origin}
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Error encoding stream event %d: %s\n", event.ID, err)
+		return
+	}
+
+	if err = config.dbQueries.NotifyStreamEvent(context.Background(), database.NotifyStreamEventParams{
+		Channel: streamChannel,
+		Payload: string(payload),
+	}); err != nil {
+		log.Printf("Error sending stream event %d to the other servers: %s\n", event.ID, err)
+	}
+}
+
+// listenForStreamEvents delivers the events published by the other servers to this server's hub using LISTEN
+func (config *apiConfig) listenForStreamEvents(ctx context.Context, dbURL string) {
+	listener := pq.NewListener(dbURL, time.Second, time.Minute, func(_ pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Error with the stream event listener: %s\n", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(streamChannel); err != nil {
+		log.Printf("Error listening for stream events: %s\n", err)
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification := <-listener.Notify:
+			// nil means the connection was lost and has just been remade
+			if notification == nil {
+				continue
+			}
+
+			event := pubsub.Event{}
+			if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+				log.Printf("Error decoding a stream event: %s\n", err)
+				continue
+			}
+			if event.Origin == config.hub.ID() {
+				continue
+			}
+			if event.ID != 0 {
+				sqlEvent, err := config.dbQueries.GetStreamEvent(ctx, event.ID)
+				if err != nil {
+					log.Printf("Error loading stream event %d: %s\n", event.ID, err)
+					continue
+				}
+				origin := event.Origin
+				event = eventFromDatabase(sqlEvent)
+				event.Origin = origin
+			}
+			config.hub.Deliver(event)
+		case <-time.After(time.Minute):
+			go listener.Ping()
+		}
+	}
+}
+
+func (config *apiConfig) streamEventCleaner(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		if _, err := config.dbQueries.DeleteOldStreamEvents(ctx, streamEventMaxAge.Seconds()); err != nil {
+			log.Printf("Error deleting old stream events: %s\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// streamHandler pushes chirp.created, chirp.updated and chirp.deleted events using Server-Sent Events.
+// Filter with ?author_id= and ?hashtag=, and resume with a Last-Event-ID header (or ?last_event_id=).
+// Browsers can't set headers on an EventSource so the JWT can also be sent as ?access_token=.
+// Chirps from users on either side of a block with the user, or that they've muted, aren't sent, nor are rechirps
+// and quotes of them
+func (config *apiConfig) streamHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		token := request.URL.Query().Get("access_token")
+		if userID, err = auth.ValidateJWT(token, config.secret); token == "" || err != nil {
+			respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+			return
+		}
+	}
+
+	flusher, ok := response.(http.Flusher)
+	if !ok {
+		respondWithError(response, request, "Streaming isn't supported", nil, http.StatusInternalServerError)
+		return
+	}
+
+	query := request.URL.Query()
+	authorID := uuid.NullUUID{}
+	if rawAuthorID := query.Get("author_id"); rawAuthorID != "" {
+		id, err := uuid.Parse(rawAuthorID)
+		if err != nil {
+			respondWithError(response, request, "There was an error parsing the UUID of the user", err, http.StatusBadRequest)
+			return
+		}
+		authorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	hashtag := entities.NormaliseHashtag(query.Get("hashtag"))
+
+	lastEventID := request.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = query.Get("last_event_id")
+	}
+	sent := newSentEvents(0)
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil {
+			respondWithError(response, request, "Last-Event-ID must be a number", err, http.StatusBadRequest)
+			return
+		}
+		sent = newSentEvents(id)
+	}
+
+	hidden := &hiddenUsers{}
+	if err = config.loadHiddenUsers(request.Context(), userID, hidden); err != nil {
+		respondWithError(response, request, "There was an error loading your blocks and mutes", err, http.StatusInternalServerError)
+		return
+	}
+
+	filter := func(event pubsub.Event) bool {
+		if event.Type != pubsub.EventChirpCreated && event.Type != pubsub.EventChirpUpdated && event.Type != pubsub.EventChirpDeleted {
+			return false
+		}
+		if authorID.Valid && event.AuthorID != authorID.UUID {
+			return false
+		}
+		if hidden.hidesEvent(event, authorID.Valid) {
+			return false
+		}
+		return hashtag == "" || slices.Contains(event.Hashtags, hashtag)
+	}
+
+	// Subscribe before reading the backlog so that nothing published in between is missed
+	subscription := config.hub.Subscribe(filter)
+	defer config.hub.Unsubscribe(subscription)
+
+	response.Header().Set("Content-Type", "text/event-stream")
+	response.Header().Set("Cache-Control", "no-cache")
+	response.Header().Set("Connection", "keep-alive")
+	response.Header().Set("X-Accel-Buffering", "no")
+	response.WriteHeader(http.StatusOK)
+	fmt.Fprint(response, "retry: 3000\n\n")
+
+	if sent.floor > 0 {
+		backlog, err := config.dbQueries.GetStreamEventsAfter(request.Context(), database.GetStreamEventsAfterParams{
+			ID:    sent.floor,
+			Limit: streamBacklogLimit,
+		})
+		if err != nil {
+			log.Printf("Error fetching the stream backlog: %s\n", err)
+			return
+		}
+		for _, sqlEvent := range backlog {
+			if !sent.add(sqlEvent.ID) {
+				continue
+			}
+			if event := eventFromDatabase(sqlEvent); filter(event) {
+				writeStreamEvent(response, event)
+			}
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+	refresh := time.NewTicker(streamHiddenUsersRefresh)
+	defer refresh.Stop()
+
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(response, ": heartbeat\n\n")
+			flusher.Flush()
+		case <-refresh.C:
+			if err := config.loadHiddenUsers(request.Context(), userID, hidden); err != nil {
+				log.Printf("Error refreshing the blocks and mutes of a stream: %s\n", err)
+			}
+		case event, ok := <-subscription.Events():
+			// The hub gave up on us for being too slow, the client will reconnect with Last-Event-ID and catch up
+			if !ok {
+				return
+			}
+			if !sent.add(event.ID) {
+				continue
+			}
+			writeStreamEvent(response, event)
+			flusher.Flush()
+		}
+	}
+}
+
+func writeStreamEvent(response http.ResponseWriter, event pubsub.Event) {
+	fmt.Fprintf(response, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+}
+
+// sentEvents remembers which events a stream has sent, so that ones from the backlog aren't sent again when they come
+// through the hub too. Two events published at the same time can arrive in either order, so only the IDs that have
+// been sent are skipped rather than everything below the highest one
+type sentEvents struct {
+	ids map[int64]struct{}
+	// Every ID up to floor counts as sent, either the client said it had them with Last-Event-ID or they've been forgotten
+	floor int64
+}
+
+func newSentEvents(floor int64) *sentEvents {
+	return &sentEvents{ids: map[int64]struct{}{}, floor: floor}
+}
+
+// add returns false when the event has already been sent. Once it is holding too many IDs it forgets the oldest half,
+// out of order events are only ever a little out of order
+func (sent *sentEvents) add(id int64) bool {
+	if _, ok := sent.ids[id]; ok || id <= sent.floor {
+		return false
+	}
+	sent.ids[id] = struct{}{}
+
+	if len(sent.ids) > 2*streamBacklogLimit {
+		ids := slices.Sorted(maps.Keys(sent.ids))
+		for _, old := range ids[:len(ids)/2] {
+			delete(sent.ids, old)
+		}
+		sent.floor = ids[len(ids)/2-1]
+	}
+	return true
+}
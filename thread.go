@@ -0,0 +1,124 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/pagination"
+)
+
+type ThreadNode struct {
+	Chirp
+	Replies []*ThreadNode `json:"replies"`
+}
+
+type Thread struct {
+	Ancestors []Chirp       `json:"ancestors"`
+	Chirp     Chirp         `json:"chirp"`
+	Replies   []*ThreadNode `json:"replies"`
+}
+
+// getThreadHandler returns the chain of chirps that a chirp is replying to (oldest first) and a page of the replies underneath it.
+// The replies are paged depth first using ?limit= and ?offset=, replies whose parent is on an earlier page sit at the top level of the page
+func (config *apiConfig) getThreadHandler(response http.ResponseWriter, request *http.Request) {
+	chirpID, err := uuid.Parse(request.PathValue("ChirpID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing that UUID", err, http.StatusBadRequest)
+		return
+	}
+
+	query := request.URL.Query()
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(response, request, err.Error(), err, http.StatusBadRequest)
+		return
+	}
+	offset, err := pagination.ParseOffset(query.Get("offset"))
+	if err != nil {
+		respondWithError(response, request, err.Error(), err, http.StatusBadRequest)
+		return
+	}
+
+	viewerID := getViewerFromHeader(request.Header, config.secret)
+	chirp, err := config.dbQueries.GetChirpForViewer(request.Context(), database.GetChirpForViewerParams{
+		ID:       chirpID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		respondWithError(response, request, "Chirp not found", err, http.StatusNotFound)
+		return
+	}
+
+	// Chirps from users on either side of a block are left out, along with the replies underneath them
+	sqlAncestors, err := config.dbQueries.GetChirpAncestors(request.Context(), database.GetChirpAncestorsParams{
+		ID:       chirpID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching the parent chirps", err, http.StatusBadRequest)
+		return
+	}
+
+	descendants, err := config.dbQueries.GetChirpDescendants(request.Context(), database.GetChirpDescendantsParams{
+		RootID:    uuid.NullUUID{UUID: chirpID, Valid: true},
+		ViewerID:  viewerID,
+		RowLimit:  int32(limit + 1),
+		RowOffset: int32(offset),
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching the replies", err, http.StatusBadRequest)
+		return
+	}
+
+	if len(descendants) > limit {
+		descendants = descendants[:limit]
+		response.Header().Set("Link", pagination.NextLink(request.URL, "offset", strconv.Itoa(offset+limit)))
+	}
+
+	thread := Thread{
+		Ancestors: []Chirp{},
+		Chirp:     chirpFromDatabase(chirp),
+		Replies:   buildReplyTree(descendants),
+	}
+	for _, ancestor := range sqlAncestors {
+		thread.Ancestors = append(thread.Ancestors, chirpFromDatabase(ancestor))
+	}
+
+	chirps := append(chirpPointers(thread.Ancestors), &thread.Chirp)
+	chirps = append(chirps, replyChirps(thread.Replies)...)
+	if err = config.hydrateChirps(request.Context(), viewerID, chirps); err != nil {
+		respondWithError(response, request, "There was an error loading the thread", err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(response, request, thread, http.StatusOK)
+}
+
+// buildReplyTree nests the depth first list of replies. Parents always come before their replies so one pass is enough
+func buildReplyTree(descendants []database.GetChirpDescendantsRow) []*ThreadNode {
+	roots := []*ThreadNode{}
+	nodes := map[uuid.UUID]*ThreadNode{}
+
+	for _, descendant := range descendants {
+		node := &ThreadNode{Chirp: chirpFromDatabase(descendant.Chirp), Replies: []*ThreadNode{}}
+		nodes[node.ID] = node
+
+		if parent, ok := nodes[descendant.Chirp.InReplyToID.UUID]; ok {
+			parent.Replies = append(parent.Replies, node)
+			continue
+		}
+		roots = append(roots, node)
+	}
+	return roots
+}
+
+func replyChirps(nodes []*ThreadNode) []*Chirp {
+	chirps := []*Chirp{}
+	for _, node := range nodes {
+		chirps = append(chirps, &node.Chirp)
+		chirps = append(chirps, replyChirps(node.Replies)...)
+	}
+	return chirps
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/pagination"
+)
+
+// getTimelineHandler returns the newest chirps from the user and the people they follow.
+// The timeline is built when a chirp is written (see FanOutChirp) so this is a single indexed read, page with ?limit= and ?before=
+func (config *apiConfig) getTimelineHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	query := request.URL.Query()
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(response, request, err.Error(), err, http.StatusBadRequest)
+		return
+	}
+
+	params := database.GetTimelineParams{
+		UserID:   userID,
+		RowLimit: int32(limit + 1),
+	}
+	if before := query.Get("before"); before != "" {
+		cursor, err := pagination.DecodeCursor(before)
+		if err != nil {
+			respondWithError(response, request, "The 'before' cursor is invalid", err, http.StatusBadRequest)
+			return
+		}
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	sqlChirps, err := config.dbQueries.GetTimeline(request.Context(), params)
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching your timeline", err, http.StatusBadRequest)
+		return
+	}
+
+	if len(sqlChirps) > limit {
+		sqlChirps = sqlChirps[:limit]
+		last := sqlChirps[len(sqlChirps)-1]
+		next := pagination.EncodeCursor(pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		response.Header().Set("Link", pagination.NextLink(request.URL, "before", next))
+	}
+
+	chirps := []Chirp{}
+	for _, chirp := range sqlChirps {
+		chirps = append(chirps, chirpFromDatabase(chirp))
+	}
+	if err = config.hydrateChirps(request.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirpPointers(chirps)); err != nil {
+		respondWithError(response, request, "There was an error loading your timeline", err, http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(response, request, chirps, http.StatusOK)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/database"
+	"github.com/vilebile17/chirpy/internal/pagination"
+)
+
+const (
+	// trashRetention is how long a deleted chirp can be restored for before the purger gets rid of it
+	trashRetention = 30 * 24 * time.Hour
+	// chirpPurgeInterval is how often the purger looks for chirps that have been in the trash too long
+	chirpPurgeInterval = time.Hour
+)
+
+// TrashedChirp is a chirp in its author's trash, with the body still there
+type TrashedChirp struct {
+	Chirp
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgesAt  time.Time `json:"purges_at"`
+}
+
+// getTrashHandler lists the user's deleted chirps, most recently deleted first. It pages with ?limit= and ?before=
+func (config *apiConfig) getTrashHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	limit, beforeDeletedAt, beforeID, ok := parseSavedPage(response, request)
+	if !ok {
+		return
+	}
+
+	rows, err := config.dbQueries.ListTrashedChirps(request.Context(), database.ListTrashedChirpsParams{
+		UserID:          userID,
+		BeforeDeletedAt: beforeDeletedAt,
+		BeforeID:        beforeID,
+		RowLimit:        int32(limit + 1),
+	})
+	if err != nil {
+		respondWithError(response, request, "There was an error fetching your trash", err, http.StatusInternalServerError)
+		return
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		next := pagination.EncodeCursor(pagination.Cursor{CreatedAt: last.DeletedAt.Time, ID: last.ID})
+		response.Header().Set("Link", pagination.NextLink(request.URL, "before", next))
+	}
+
+	trash := []TrashedChirp{}
+	for _, row := range rows {
+		chirp := chirpFromDatabase(row)
+		// The author can still read their own deleted chirps, and they aren't marked as deleted yet so that their
+		// media and poll get filled in too
+		chirp.Body = row.Body
+		chirp.ContentWarning = row.ContentWarning
+		chirp.Deleted = false
+		trash = append(trash, TrashedChirp{
+			Chirp:     chirp,
+			DeletedAt: row.DeletedAt.Time,
+			PurgesAt:  row.DeletedAt.Time.Add(trashRetention),
+		})
+	}
+
+	chirps := make([]*Chirp, 0, len(trash))
+	for i := range trash {
+		chirps = append(chirps, &trash[i].Chirp)
+	}
+	if err = config.hydrateChirps(request.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirps); err != nil {
+		respondWithError(response, request, "There was an error loading the chirps", err, http.StatusInternalServerError)
+		return
+	}
+	for i := range trash {
+		trash[i].Deleted = true
+	}
+	respondWithJSON(response, request, trash, http.StatusOK)
+}
+
+// restoreChirpHandler takes {ChirpID} back out of the user's trash, along with any rechirps it had
+func (config *apiConfig) restoreChirpHandler(response http.ResponseWriter, request *http.Request) {
+	_, userID, err := getJWTFromHeader(request.Header, config.secret)
+	if err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(request.PathValue("ChirpID"))
+	if err != nil {
+		respondWithError(response, request, "There was an error parsing that UUID", err, http.StatusBadRequest)
+		return
+	}
+
+	chirp, err := config.dbQueries.GetTrashedChirp(request.Context(), database.GetTrashedChirpParams{ID: chirpID, UserID: userID})
+	if err != nil {
+		respondWithError(response, request, "That chirp isn't in your trash", err, http.StatusNotFound)
+		return
+	}
+	// The purger only runs every so often, so the chirp might still be here after it has expired
+	if time.Now().UTC().After(chirp.DeletedAt.Time.Add(trashRetention)) {
+		respondWithError(response, request, "That chirp has been in the trash for too long to be restored", nil, http.StatusGone)
+		return
+	}
+
+	if err = config.dbQueries.RestoreChirp(request.Context(), chirpID); err != nil {
+		respondWithError(response, request, "There was an error restoring the chirp", err, http.StatusInternalServerError)
+		return
+	}
+	chirp.DeletedAt = sql.NullTime{}
+
+	result, err := config.announceChirp(request.Context(), chirp)
+	if err != nil {
+		respondWithError(response, request, "There was an error loading the chirp", err, http.StatusInternalServerError)
+		return
+	}
+	respondWithJSON(response, request, result, http.StatusOK)
+}
+
+// chirpPurger deletes chirps for good once they've been in the trash for longer than trashRetention. Like the
+// scheduled chirp publisher, each chirp is locked while it's purged so that servers don't trip over each other
+func (config *apiConfig) chirpPurger(ctx context.Context) {
+	ticker := time.NewTicker(chirpPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			purged, err := config.purgeTrashedChirp(ctx)
+			if err != nil {
+				log.Printf("Error purging a deleted chirp: %s\n", err)
+				break
+			}
+			if !purged {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeTrashedChirp purges the chirp that has been in the trash the longest, if it has been there too long. It returns
+// false when there was nothing to do
+func (config *apiConfig) purgeTrashedChirp(ctx context.Context) (bool, error) {
+	tx, err := config.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	queries := config.dbQueries.WithTx(tx)
+
+	chirp, err := queries.ClaimPurgeableChirp(ctx, trashRetention.Seconds())
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if err = purgeChirp(ctx, queries, chirp.ID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
@@ -3,11 +3,14 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/vilebile17/chirpy/internal/auth"
 	"github.com/vilebile17/chirpy/internal/database"
 )
@@ -16,16 +19,25 @@ func (config *apiConfig) registerUser(response http.ResponseWriter, request *htt
 	type IncomingJSON struct {
 		Password string `json:"password"`
 		Email    string `json:"email"`
+		Handle   string `json:"handle"`
 	}
 
 	decoder := json.NewDecoder(request.Body)
 	incomingjson := IncomingJSON{}
 	err := decoder.Decode(&incomingjson)
 	if err != nil {
-		respondWithError(response, request, "Something went wrong, required format: {'email':'EMAIL', 'password':'PASSWORD'}", err, http.StatusBadRequest)
+		respondWithError(response, request, "Something went wrong, required format: {'email':'EMAIL', 'password':'PASSWORD', 'handle':'HANDLE(optional)'}", err, http.StatusBadRequest)
 		return
 	}
 
+	handle := sql.NullString{}
+	if incomingjson.Handle != "" {
+		if !validateHandle(response, request, incomingjson.Handle) {
+			return
+		}
+		handle = sql.NullString{String: incomingjson.Handle, Valid: true}
+	}
+
 	email := incomingjson.Email
 	hashedPassword, err := auth.HashPassword(incomingjson.Password)
 	if err != nil {
@@ -36,8 +48,13 @@ func (config *apiConfig) registerUser(response http.ResponseWriter, request *htt
 	sqlUser, err := config.dbQueries.CreateUser(request.Context(), database.CreateUserParams{
 		Email:          email,
 		HashedPassword: hashedPassword,
+		Handle:         handle,
 	})
 	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(response, request, "That email or handle is already taken", err, http.StatusConflict)
+			return
+		}
 		fmt.Println(err)
 		respondWithError(response, request, "An error occured when making the user...", err, http.StatusBadRequest)
 		return
@@ -49,8 +66,9 @@ func (config *apiConfig) registerUser(response http.ResponseWriter, request *htt
 		UpdatedAt   time.Time `json:"updated_at"`
 		Email       string    `json:"email"`
 		IsChirpyRed bool      `json:"is_chirpy_red"`
+		Handle      string    `json:"handle,omitempty"`
 	}
-	user := User{sqlUser.ID, sqlUser.CreatedAt, sqlUser.UpdatedAt, sqlUser.Email, sqlUser.IsChirpyRed}
+	user := User{sqlUser.ID, sqlUser.CreatedAt, sqlUser.UpdatedAt, sqlUser.Email, sqlUser.IsChirpyRed, sqlUser.Handle.String}
 	respondWithJSON(response, request, user, http.StatusCreated)
 }
 
@@ -103,12 +121,20 @@ func (config *apiConfig) loginHandler(response http.ResponseWriter, request *htt
 		return
 	}
 
+	if err = config.emitNotification(request.Context(), user.ID, NotificationNewLogin, map[string]string{
+		"ip_address": request.RemoteAddr,
+		"user_agent": request.UserAgent(),
+	}); err != nil {
+		log.Printf("Error sending the new login notification: %s\n", err)
+	}
+
 	type User struct {
 		ID           uuid.UUID `json:"id"`
 		CreatedAt    time.Time `json:"created_at"`
 		UpdatedAt    time.Time `json:"updated_at"`
 		Email        string    `json:"email"`
 		IsChirpyRed  bool      `json:"is_chirpy_red"`
+		Handle       string    `json:"handle,omitempty"`
 		Token        string    `json:"token"`
 		RefreshToken string    `json:"refresh_token"`
 	}
@@ -118,11 +144,18 @@ func (config *apiConfig) loginHandler(response http.ResponseWriter, request *htt
 		user.UpdatedAt,
 		user.Email,
 		user.IsChirpyRed,
+		user.Handle.String,
 		jwt,
 		refreshToken,
 	}, http.StatusOK)
 }
 
+// isUniqueViolation reports whether a query failed because of a UNIQUE constraint, e.g. an email that is already taken
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
 func getJWTFromHeader(header http.Header, secret string) (string, uuid.UUID, error) {
 	jwtToken, err := auth.GetBearerToken(header)
 	if err != nil {
@@ -135,6 +168,16 @@ func getJWTFromHeader(header http.Header, secret string) (string, uuid.UUID, err
 	return jwtToken, userID, nil
 }
 
+// getViewerFromHeader is for endpoints that work without logging in but show more to a logged in user.
+// A missing or invalid JWT just means the viewer is anonymous
+func getViewerFromHeader(header http.Header, secret string) uuid.NullUUID {
+	_, userID, err := getJWTFromHeader(header, secret)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: userID, Valid: true}
+}
+
 func (config *apiConfig) updateDetailsHandler(response http.ResponseWriter, request *http.Request) {
 	_, userID, err := getJWTFromHeader(request.Header, config.secret)
 	if err != nil {
@@ -145,6 +188,7 @@ func (config *apiConfig) updateDetailsHandler(response http.ResponseWriter, requ
 	type IncomingJSON struct {
 		Password string `json:"password"`
 		Email    string `json:"email"`
+		Handle   string `json:"handle"`
 	}
 
 	decoder := json.NewDecoder(request.Body)
@@ -161,7 +205,27 @@ func (config *apiConfig) updateDetailsHandler(response http.ResponseWriter, requ
 		return
 	}
 
-	user, err := config.dbQueries.UpdateUserEmailAndPassword(request.Context(), database.UpdateUserEmailAndPasswordParams{
+	oldUser, err := config.dbQueries.GetUserByID(request.Context(), userID)
+	if err != nil {
+		respondWithError(response, request, "Couldn't find the user...", err, http.StatusNotFound)
+		return
+	}
+
+	// The handle is checked before anything is saved, and everything is saved together, so that a handle that can't be
+	// used doesn't leave the email and password changed
+	if incomingjson.Handle != "" && !checkHandleChange(response, request, oldUser, incomingjson.Handle) {
+		return
+	}
+
+	tx, err := config.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(response, request, "There was an error when updating the user...", err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	queries := config.dbQueries.WithTx(tx)
+
+	user, err := queries.UpdateUserEmailAndPassword(request.Context(), database.UpdateUserEmailAndPasswordParams{
 		ID:             userID,
 		Email:          incomingjson.Email,
 		HashedPassword: hashedPassword,
@@ -171,11 +235,39 @@ func (config *apiConfig) updateDetailsHandler(response http.ResponseWriter, requ
 		return
 	}
 
+	if incomingjson.Handle != "" {
+		var ok bool
+		if user, ok = config.setHandle(response, request, queries, user, incomingjson.Handle); !ok {
+			return
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		respondWithError(response, request, "There was an error when updating the user...", err, http.StatusInternalServerError)
+		return
+	}
+
+	if oldUser.Email != user.Email {
+		if err = config.emitNotification(request.Context(), userID, NotificationEmailChanged, map[string]string{
+			"old_email": oldUser.Email,
+			"new_email": user.Email,
+		}); err != nil {
+			log.Printf("Error sending the email changed notification: %s\n", err)
+		}
+	}
+	if samePassword, err := auth.CheckPasswordHash(incomingjson.Password, oldUser.HashedPassword); err != nil || !samePassword {
+		if err = config.emitNotification(request.Context(), userID, NotificationPasswordChanged, nil); err != nil {
+			log.Printf("Error sending the password changed notification: %s\n", err)
+		}
+	}
+
 	respondWithJSON(response, nil, struct {
 		Email     string    `json:"email"`
+		Handle    string    `json:"handle,omitempty"`
 		UpdatedAt time.Time `json:"updated_at"`
 	}{
 		Email:     user.Email,
+		Handle:    user.Handle.String,
 		UpdatedAt: user.UpdatedAt,
 	}, http.StatusOK)
 }
@@ -223,5 +315,11 @@ func (config *apiConfig) upgradePlanHandler(response http.ResponseWriter, reques
 		respondWithError(response, request, "Something went wrong whilst updating the chirpy red for the account", err, http.StatusBadRequest)
 		return
 	}
+
+	if err = config.emitNotification(request.Context(), userID, NotificationPlanUpgraded, map[string]string{
+		"plan": "chirpy_red",
+	}); err != nil {
+		log.Printf("Error sending the plan upgraded notification: %s\n", err)
+	}
 	response.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vilebile17/chirpy/internal/auth"
+	"github.com/vilebile17/chirpy/internal/pubsub"
+	"github.com/vilebile17/chirpy/internal/websocket"
+)
+
+const (
+	wsPingInterval = 30 * time.Second
+	// The client has this long to answer a ping (or say anything else) before we give up on it
+	wsReadTimeout  = 2*wsPingInterval + 10*time.Second
+	wsWriteTimeout = 10 * time.Second
+	// How long before the JWT runs out that the client is asked for a new one
+	wsReauthWarning = time.Minute
+	wsMaxAuthors    = 100
+)
+
+const (
+	TopicChirps = "chirps"
+	TopicAuthor = "author"
+)
+
+// wsClientMessage is anything the client can send:
+//
+//	{"type": "subscribe", "topic": "chirps"}
+//	{"type": "subscribe", "topic": "author", "author_id": "..."}
+//	{"type": "unsubscribe", ...same as subscribe}
+//	{"type": "auth", "token": "<a new JWT>"}
+//	{"type": "ping"}
+type wsClientMessage struct {
+	Type     string    `json:"type"`
+	Topic    string    `json:"topic"`
+	AuthorID uuid.UUID `json:"author_id"`
+	Token    string    `json:"token"`
+}
+
+// wsServerMessage is everything we send back. Events go out as "chirp.created", "chirp.updated", "chirp.deleted",
+// "notification.created" and "message.created", chirps have the same data as /api/stream
+type wsServerMessage struct {
+	Type      string          `json:"type"`
+	ID        int64           `json:"id,omitempty"`
+	Topic     string          `json:"topic,omitempty"`
+	AuthorID  *uuid.UUID      `json:"author_id,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// wsClient keeps track of what one connection is subscribed to. The hub calls filter from other goroutines so it is locked
+type wsClient struct {
+	userID uuid.UUID
+	hidden *hiddenUsers
+
+	mu        sync.Mutex
+	allChirps bool
+	authors   map[uuid.UUID]struct{}
+}
+
+func (client *wsClient) filter(event pubsub.Event) bool {
+	if event.RecipientID != uuid.Nil {
+		return event.RecipientID == client.userID
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	_, askedFor := client.authors[event.AuthorID]
+	if !client.allChirps && !askedFor {
+		return false
+	}
+	return !client.hidden.hidesEvent(event, askedFor)
+}
+
+// subscribe turns a topic on or off
+func (client *wsClient) subscribe(message wsClientMessage, on bool) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	switch message.Topic {
+	case TopicChirps:
+		client.allChirps = on
+	case TopicAuthor:
+		if message.AuthorID == uuid.Nil {
+			return errors.New("author_id is required for the author topic")
+		}
+		if !on {
+			delete(client.authors, message.AuthorID)
+			return nil
+		}
+		if len(client.authors) >= wsMaxAuthors {
+			return errors.New("you can't subscribe to any more authors")
+		}
+		client.authors[message.AuthorID] = struct{}{}
+	default:
+		return errors.New("unknown topic, use 'chirps' or 'author'")
+	}
+	return nil
+}
+
+// wsHandler is a WebSocket version of /api/stream that the client can talk back to. It takes the JWT from the
+// Authorization header or ?access_token=, the user's own notifications and direct messages are always sent. Chirps from
+// users on either side of a block with the user, or that they've muted, are left out like they are on /api/stream.
+// When the JWT is about to run out a "reauth_required" message is sent, if no new token arrives in time the connection is closed
+func (config *apiConfig) wsHandler(response http.ResponseWriter, request *http.Request) {
+	token, err := auth.GetBearerToken(request.Header)
+	if err != nil {
+		token = request.URL.Query().Get("access_token")
+	}
+	userID, expiresAt, err := auth.ValidateJWTWithExpiry(token, config.secret)
+	if token == "" || err != nil {
+		respondWithError(response, request, "There was an error validating the JWT", err, http.StatusUnauthorized)
+		return
+	}
+
+	client := &wsClient{userID: userID, hidden: &hiddenUsers{}, authors: map[uuid.UUID]struct{}{}}
+	if err = config.loadHiddenUsers(request.Context(), userID, client.hidden); err != nil {
+		respondWithError(response, request, "There was an error loading your blocks and mutes", err, http.StatusInternalServerError)
+		return
+	}
+
+	conn, err := websocket.Upgrade(response, request)
+	if err != nil {
+		respondWithError(response, request, "Couldn't upgrade to a WebSocket", err, http.StatusBadRequest)
+		return
+	}
+	defer conn.Close()
+
+	subscription := config.hub.Subscribe(client.filter)
+	defer config.hub.Unsubscribe(subscription)
+
+	// The reader only hands replies and new expiry times to this goroutine, so that only one goroutine writes
+	replies := make(chan wsServerMessage, 16)
+	newExpiry := make(chan time.Time, 1)
+	done := make(chan struct{})
+	go config.readWSMessages(conn, client, replies, newExpiry, done)
+
+	send := func(message wsServerMessage) error {
+		encoded, err := json.Marshal(message)
+		if err != nil {
+			return err
+		}
+		conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		return conn.WriteMessage(websocket.TextMessage, encoded)
+	}
+	if err = send(wsServerMessage{Type: "authenticated", ExpiresAt: expiryPointer(expiresAt)}); err != nil {
+		return
+	}
+
+	pinger := time.NewTicker(wsPingInterval)
+	defer pinger.Stop()
+	refresh := time.NewTicker(streamHiddenUsersRefresh)
+	defer refresh.Stop()
+
+	// Timers that never fire stand in for a token without an expiry
+	warning := time.NewTimer(time.Until(expiresAt.Add(-wsReauthWarning)))
+	expiry := time.NewTimer(time.Until(expiresAt))
+	if expiresAt.IsZero() {
+		warning.Stop()
+		expiry.Stop()
+	}
+	defer warning.Stop()
+	defer expiry.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case message := <-replies:
+			err = send(message)
+		case expiresAt = <-newExpiry:
+			warning.Stop()
+			expiry.Stop()
+			if !expiresAt.IsZero() {
+				warning.Reset(time.Until(expiresAt.Add(-wsReauthWarning)))
+				expiry.Reset(time.Until(expiresAt))
+			}
+		case <-warning.C:
+			err = send(wsServerMessage{Type: "reauth_required", ExpiresAt: &expiresAt})
+		case <-expiry.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			conn.WriteClose(websocket.ClosePolicyViolation, "token expired")
+			return
+		case <-pinger.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			err = conn.WriteMessage(websocket.PingMessage, nil)
+		case <-refresh.C:
+			if err := config.loadHiddenUsers(request.Context(), userID, client.hidden); err != nil {
+				log.Printf("Error refreshing the blocks and mutes of a WebSocket: %s\n", err)
+			}
+		case event, ok := <-subscription.Events():
+			// The hub dropped us for falling behind, the client should reconnect and catch up over the REST API
+			if !ok {
+				conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+				conn.WriteClose(websocket.CloseTryAgainLater, "too slow, please reconnect")
+				return
+			}
+			message := wsServerMessage{Type: event.Type, ID: event.ID, Data: event.Data}
+			if event.RecipientID == uuid.Nil {
+				message.AuthorID = &event.AuthorID
+			}
+			err = send(message)
+		}
+
+		if err != nil {
+			log.Printf("Error writing to a WebSocket: %s\n", err)
+			return
+		}
+	}
+}
+
+// readWSMessages handles everything the client sends until the connection goes away, then closes done
+func (config *apiConfig) readWSMessages(conn *websocket.Conn, client *wsClient, replies chan<- wsServerMessage, newExpiry chan time.Time, done chan<- struct{}) {
+	defer close(done)
+
+	reply := func(message wsServerMessage) {
+		select {
+		case replies <- message:
+		case <-time.After(wsWriteTimeout):
+		}
+	}
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		// Pongs only need to push the read deadline back
+		if messageType != websocket.TextMessage {
+			continue
+		}
+
+		message := wsClientMessage{}
+		if err = json.Unmarshal(data, &message); err != nil {
+			reply(wsServerMessage{Type: "error", Error: "Messages must be JSON objects"})
+			continue
+		}
+
+		switch message.Type {
+		case "subscribe", "unsubscribe":
+			if err = client.subscribe(message, message.Type == "subscribe"); err != nil {
+				reply(wsServerMessage{Type: "error", Error: err.Error()})
+				continue
+			}
+			confirmation := wsServerMessage{Type: message.Type + "d", Topic: message.Topic}
+			if message.Topic == TopicAuthor {
+				confirmation.AuthorID = &message.AuthorID
+			}
+			reply(confirmation)
+		case "auth":
+			userID, expiresAt, err := auth.ValidateJWTWithExpiry(message.Token, config.secret)
+			if err != nil {
+				reply(wsServerMessage{Type: "error", Error: "There was an error validating the JWT"})
+				continue
+			}
+			if userID != client.userID {
+				reply(wsServerMessage{Type: "error", Error: "The new JWT belongs to a different user"})
+				continue
+			}
+			// Swap out any expiry time that the writer hasn't picked up yet
+			select {
+			case <-newExpiry:
+			default:
+			}
+			newExpiry <- expiresAt
+			reply(wsServerMessage{Type: "authenticated", ExpiresAt: expiryPointer(expiresAt)})
+		case "ping":
+			reply(wsServerMessage{Type: "pong"})
+		default:
+			reply(wsServerMessage{Type: "error", Error: "Unknown message type"})
+		}
+	}
+}
+
+func expiryPointer(expiresAt time.Time) *time.Time {
+	if expiresAt.IsZero() {
+		return nil
+	}
+	return &expiresAt
+}